@@ -2,15 +2,19 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"net/http"
+	"os"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 
 	"github.com/vincent-vinf/code-validator/pkg/orm"
 	"github.com/vincent-vinf/code-validator/pkg/perform"
+	"github.com/vincent-vinf/code-validator/pkg/sandbox"
 	"github.com/vincent-vinf/code-validator/pkg/types"
 	"github.com/vincent-vinf/code-validator/pkg/util"
 	"github.com/vincent-vinf/code-validator/pkg/util/config"
@@ -37,11 +41,17 @@ func main() {
 	}
 	db.Init(cfg.Mysql)
 	defer db.Close()
-	ossClient, err := oss.NewClient(cfg.Minio)
+	storage, err := oss.NewStorage(cfg.Minio, cfg.Storage)
 	if err != nil {
 		log.Fatal(err)
 	}
-	perform.SetOssClient(ossClient)
+	perform.SetStorage(storage)
+	if err = perform.LoadRuntimes(cfg.Runtimes); err != nil {
+		log.Fatal(err)
+	}
+	perform.SetDependencyConfig(cfg.Dependency)
+	sandbox.SetCgroup(cfg.Sandbox.Cgroup)
+	perform.SetPinnedCPUs(cfg.Sandbox.CPUs)
 
 	http.Handle("/metrics", promhttp.Handler())
 	go func() {
@@ -51,18 +61,57 @@ func main() {
 		}
 	}()
 
-	if err = dealQueue(cfg); err != nil {
+	if err = dealQueues(cfg); err != nil {
 		log.Fatal(err)
 	}
 }
 
-func dealQueue(cfg config.Config) error {
-	mqClient, err := mq.NewClient(perform.Runtime, cfg.RabbitMQ)
+// dealQueues consumes the queue of every runtime that this host can serve,
+// it returns when any of the queues fails
+func dealQueues(cfg config.Config) error {
+	specs := perform.AvailableRuntimes()
+	if len(specs) == 0 {
+		return errors.New("no runtime is available on this host")
+	}
+	runtimes := make([]types.Runtime, 0, len(specs))
+	for _, spec := range specs {
+		runtimes = append(runtimes, spec.Runtime)
+	}
+	go advertise(runtimes)
+
+	errCh := make(chan error, len(specs))
+	for _, rt := range runtimes {
+		go func(routeKey string) {
+			errCh <- dealQueue(routeKey, cfg)
+		}(rt.RouteKey())
+	}
+
+	return <-errCh
+}
+
+// advertise periodically records the runtimes served by this host, so that batches can only pin served versions
+func advertise(runtimes []types.Runtime) {
+	host, err := os.Hostname()
+	if err != nil {
+		log.Warn(err)
+
+		return
+	}
+	for {
+		if err = db.AdvertiseRuntimes(host, runtimes); err != nil {
+			log.Warn(err)
+		}
+		time.Sleep(types.RuntimeAdvertiseInterval)
+	}
+}
+
+func dealQueue(routeKey string, cfg config.Config) error {
+	mqClient, err := mq.NewClient(routeKey, cfg.RabbitMQ)
 	if err != nil {
 		return err
 	}
 	defer mqClient.Close()
-	log.Info("start deal queue")
+	log.Infof("start deal queue of %s", routeKey)
 	if err = mqClient.Consume(
 		func(data []byte) {
 			req := &types.SubTaskRequest{}
@@ -72,7 +121,14 @@ func dealQueue(cfg config.Config) error {
 
 				return
 			}
-			if err := subTaskHandle(req); err != nil {
+			handle := subTaskHandle
+			switch req.Kind {
+			case types.RequestKindGenerate:
+				handle = generateHandle
+			case types.RequestKindCheckInputs:
+				handle = checkInputsHandle
+			}
+			if err := handle(req); err != nil {
 				log.Warn(err)
 
 				return
@@ -119,37 +175,134 @@ func subTaskHandle(req *types.SubTaskRequest) error {
 	defer func() {
 		subtask.Status = types.TaskStatusFinish
 		_ = db.UpdateSubTask(subtask)
+		_ = db.UpdateTaskScore(subtask.TaskID)
+		if task.Kind == types.TaskKindReference {
+			if err := db.UpdateReferenceStatus(task.ID); err != nil {
+				log.Error("update the status of the batch: ", err)
+			}
+		}
 	}()
 
+	sub := perform.Submission{
+		OssPath: oss.GetCodePath(req.TaskID),
+	}
+	if task.CodeType != "" {
+		sub.OssPath = oss.GetCodeArchivePath(req.TaskID, task.CodeType)
+		sub.Archive = task.CodeType
+	}
 	report, err := perform.Perform(v,
-		oss.GetCodePath(req.TaskID),
+		sub,
 		oss.GetBatchDir(task.BatchID),
 		oss.GetVerificationDir(req.TaskID, req.VerificationID),
 	)
 	if err != nil {
 		subtask.Result = types.TaskStatusFailed
+		subtask.Verdict = string(perform.VerdictInternalError)
 		subtask.Message = err.Error()
 		return nil
 	}
 	util.LogStruct(report)
 
-	if report.Pass {
+	switch {
+	case report.Pass:
 		subtask.Result = types.TaskStatusSuccess
-	} else {
+	case report.Verdict == perform.VerdictDependencyError:
+		subtask.Result = types.TaskStatusDependencyFailed
+	default:
 		subtask.Result = types.TaskStatusFailed
 	}
-	if len(report.Cases) > 0 {
-		var passNum int
-		util.LogStruct(report.Cases)
-		for _, c := range report.Cases {
-			if c.Pass {
-				passNum++
+	subtask.Verdict = string(report.Verdict)
+	subtask.Message = report.Summary()
+	subtask.Score = report.Score
+	subtask.MaxScore = report.MaxScore
+	// a report that cannot be encoded only loses the details of the cases
+	subtask.Report, _ = json.Marshal(report)
+
+	return nil
+}
+
+// generateHandle replaces the cases of the verification by the generated ones,
+// the subtask of the task is run after the generation when it is set
+func generateHandle(req *types.SubTaskRequest) error {
+	vf, err := getVerificationByID(req.VerificationID)
+	if err != nil {
+		return err
+	}
+	v := &perform.Verification{}
+	if err = json.Unmarshal([]byte(vf.Data), v); err != nil {
+		return err
+	}
+
+	cases, gen, err := perform.Generate(v, oss.GetBatchDir(vf.BatchID))
+	if err != nil {
+		gen = &perform.Generation{
+			CreatedAt: time.Now(),
+			Message:   err.Error(),
+		}
+	}
+	if v.Code != nil && v.Code.Generator != nil {
+		if gen.Message == "" {
+			v.Code.Cases = cases
+			if v.Code.Validator != nil {
+				checkInputs(vf, v)
 			}
+		} else {
+			log.Warnf("generate the cases of verification %d: %s", vf.ID, gen.Message)
+		}
+		v.Code.Generator.Generated = gen
+		if err = updateVerification(vf.ID, v); err != nil {
+			return err
 		}
-		subtask.Message = fmt.Sprintf("%d/%d", passNum, len(report.Cases))
-	} else {
-		subtask.Message = report.Message
 	}
 
-	return nil
+	if req.TaskID == 0 {
+		return nil
+	}
+
+	return subTaskHandle(&types.SubTaskRequest{
+		TaskID:         req.TaskID,
+		VerificationID: req.VerificationID,
+	})
+}
+
+// checkInputsHandle runs the input validator on the cases of the verification
+func checkInputsHandle(req *types.SubTaskRequest) error {
+	vf, err := getVerificationByID(req.VerificationID)
+	if err != nil {
+		return err
+	}
+	v := &perform.Verification{}
+	if err = json.Unmarshal([]byte(vf.Data), v); err != nil {
+		return err
+	}
+	if v.Code == nil || v.Code.Validator == nil {
+		return fmt.Errorf("verification %d has no input validator", vf.ID)
+	}
+	checkInputs(vf, v)
+
+	return updateVerification(vf.ID, v)
+}
+
+// checkInputs records the result of the input validator in the verification
+func checkInputs(vf *orm.Verification, v *perform.Verification) {
+	check, err := perform.CheckInputs(v, oss.GetBatchDir(vf.BatchID))
+	if err != nil {
+		check = &perform.InputCheck{
+			CreatedAt: time.Now(),
+			Message:   err.Error(),
+		}
+	}
+	if !check.Valid() {
+		log.Warnf("the inputs of verification %d are invalid: %s %v", vf.ID, check.Message, check.Invalid)
+	}
+	v.Code.InputCheck = check
+}
+
+func updateVerification(id int, v *perform.Verification) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return db.UpdateVerificationData(id, string(data))
 }
@@ -1,12 +1,13 @@
 package main
 
 import (
-	"bytes"
 	"fmt"
 	"os"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+
+	"github.com/vincent-vinf/code-validator/pkg/checker"
 )
 
 var (
@@ -20,13 +21,17 @@ var (
 			logrus.Info("You need to use subcommands, use --help for more information")
 		},
 	}
+	matchOpt = checker.Options{}
 	matchCmd = &cobra.Command{
-		Use:   "match",
-		Short: "Exactly match 2 files (remove carriage return at end of file)",
+		Use:   "match <output> <answer>",
+		Short: "Match the output with the answer, the default mode is exact (remove carriage return at end of file)",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) != 2 {
 				return fmt.Errorf("parameter mismatch")
 			}
+			if err := matchOpt.Validate(); err != nil {
+				return err
+			}
 			d1, err := os.ReadFile(args[0])
 			if err != nil {
 				return err
@@ -35,11 +40,13 @@ var (
 			if err != nil {
 				return err
 			}
-			d1 = bytes.TrimRight(d1, "\n")
-			d2 = bytes.TrimRight(d2, "\n")
 
-			if !bytes.Equal(d1, d2) {
-				logger.Info("fail")
+			pass, msg, err := checker.Check(matchOpt, d1, d2)
+			if err != nil {
+				return err
+			}
+			if !pass {
+				logger.Info("fail: ", msg)
 
 				os.Exit(1)
 			}
@@ -51,6 +58,10 @@ var (
 )
 
 func init() {
+	matchCmd.Flags().StringVar((*string)(&matchOpt.Mode), "mode", string(checker.Exact),
+		"exact, tokens, ignore-case, float, unordered or regex")
+	matchCmd.Flags().Float64Var(&matchOpt.AbsEps, "abs-eps", 0, "absolute epsilon of the float mode")
+	matchCmd.Flags().Float64Var(&matchOpt.RelEps, "rel-eps", 0, "relative epsilon of the float mode")
 	rootCmd.AddCommand(matchCmd)
 }
 
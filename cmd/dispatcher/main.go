@@ -1,10 +1,13 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"net/http"
 	"os"
@@ -21,12 +24,14 @@ import (
 	"github.com/vincent-vinf/code-validator/pkg/perform"
 	"github.com/vincent-vinf/code-validator/pkg/types"
 	"github.com/vincent-vinf/code-validator/pkg/util"
+	"github.com/vincent-vinf/code-validator/pkg/util/archive"
 	"github.com/vincent-vinf/code-validator/pkg/util/config"
 	"github.com/vincent-vinf/code-validator/pkg/util/db"
 	"github.com/vincent-vinf/code-validator/pkg/util/jwtx"
 	"github.com/vincent-vinf/code-validator/pkg/util/mq"
 	"github.com/vincent-vinf/code-validator/pkg/util/oss"
 	"github.com/vincent-vinf/code-validator/pkg/util/zip"
+	"github.com/vincent-vinf/code-validator/pkg/vo"
 )
 
 const (
@@ -37,6 +42,10 @@ const (
 	defaultCaseDir        = "cases"
 
 	defaultContentType = gin.MIMEPlain
+	archiveContentType = "application/octet-stream"
+
+	// an actuator that has not advertised its runtimes for a while is considered gone
+	runtimeExpiration = 3 * types.RuntimeAdvertiseInterval
 )
 
 var (
@@ -44,8 +53,11 @@ var (
 	log        = logrus.New()
 	port       = flag.Int("port", 8001, "")
 
+	similarityInterval = flag.Duration("similarity-interval", time.Minute,
+		"the minimum interval between two computations of the similarity of a batch")
+
 	pubClient *mq.PubClient
-	ossClient *oss.Client
+	storage   oss.Storage
 )
 
 func init() {
@@ -61,10 +73,14 @@ func main() {
 	db.Init(cfg.Mysql)
 	defer db.Close()
 
-	ossClient, err = oss.NewClient(cfg.Minio)
+	storage, err = oss.NewStorage(cfg.Minio, cfg.Storage)
 	if err != nil {
 		log.Fatal(err)
 	}
+	// the registered runtimes are accepted when no actuator has advertised them recently, such as during a restart
+	if err = perform.LoadRuntimes(cfg.Runtimes); err != nil {
+		log.Fatal(err)
+	}
 
 	pubClient, err = mq.NewPubClient(cfg.RabbitMQ)
 	if err != nil {
@@ -89,6 +105,7 @@ func main() {
 
 	router := r.Group(util.WithGlobalAPIPrefix("/batch"))
 	router.Use(authMiddleware.MiddlewareFunc())
+	router.GET("/runtime", getRuntimes)
 	router.GET("/:id", getBatchByID)
 	router.GET("", getBatchList)
 	router.POST("", addBatch)
@@ -96,7 +113,16 @@ func main() {
 	router.POST("/case/file", uploadCaseFile)
 	router.POST("/case", uploadCase)
 
+	router.POST("/:id/validate", validateBatch)
+	router.GET("/:id/validation", getBatchValidation)
+	router.POST("/:id/generate", generateCases)
+	router.GET("/:id/similarity", getBatchSimilarity)
+	router.POST("/:id/similarity", refreshBatchSimilarity)
+
 	router.POST("/task", newTaskOfBatch)
+	router.POST("/task/file", newProjectTaskOfBatch)
+
+	go similarityJobs.run(*similarityInterval)
 
 	util.WatchSignalGrace(r, *port)
 }
@@ -109,9 +135,51 @@ func getBatchByID(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, jsend.SimpleErr(err.Error()))
 		return
 	}
+	if batch.UserID != getUserIDFromReq(c) {
+		if err = forStudent(batch); err != nil {
+			c.JSON(http.StatusInternalServerError, jsend.SimpleErr(err.Error()))
+			return
+		}
+	}
 	c.JSON(http.StatusOK, jsend.Success(batch))
 }
 
+// forStudent removes what the students cannot see from the verifications of the batch
+func forStudent(batch *orm.Batch) error {
+	afterDeadline := batch.Deadline != nil && time.Now().After(*batch.Deadline)
+	for _, vf := range batch.Verifications {
+		v := &perform.Verification{}
+		if err := json.Unmarshal([]byte(vf.Data), v); err != nil {
+			return err
+		}
+		v, err := v.ForStudent(afterDeadline)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		vf.Data = string(data)
+	}
+
+	return nil
+}
+
+// getRuntimes lists the runtimes that are served by at least one actuator
+func getRuntimes(c *gin.Context) {
+	runtimes, err := listServedRuntimes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, jsend.SimpleErr(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, jsend.Success(runtimes))
+}
+
+func listServedRuntimes() ([]types.Runtime, error) {
+	return db.ListRuntimes(time.Now().Add(-runtimeExpiration))
+}
+
 func getBatchList(c *gin.Context) {
 	batch, err := db.ListBatchWithUserName()
 	if err != nil {
@@ -132,15 +200,68 @@ func addBatch(c *gin.Context) {
 		Name          string
 		Describe      string
 		Runtime       string
+		Version       string
 		Verifications []*perform.Verification
+		// Reference is the solution of the author, the batch is validated by it when set
+		Reference *codeReq
+		// Validator is the default input validator of the code verifications
+		Validator *perform.Program
+		// Deadline reveals the cases that are hidden until it
+		Deadline *time.Time
 	}
 	req := &Request{}
 	if err = c.BindJSON(req); err != nil {
 		return
 	}
+	served, err := listServedRuntimes()
+	if err != nil {
+		return
+	}
+	hosts, err := db.ListHostRuntimes(time.Now().Add(-runtimeExpiration))
+	if err != nil {
+		return
+	}
 
 	var vfs []*orm.Verification
 	for _, vf := range req.Verifications {
+		if vf.Version == "" && vf.Runtime == req.Runtime {
+			vf.Version = req.Version
+		}
+		if !containsRuntime(served, vf.GetRuntime()) {
+			if _, ok := perform.Lookup(vf.GetRuntime()); !ok {
+				err = fmt.Errorf("runtime %s is not served by any actuator", vf.GetRuntime())
+				return
+			}
+			// the tasks wait in the queue of the runtime until an actuator serves it again
+			log.Warnf("runtime %s of verification %s is not advertised by any actuator", vf.GetRuntime(), vf.Name)
+		}
+		if vf.Code != nil {
+			if vf.Code.Validator == nil && req.Validator != nil {
+				validator := *req.Validator
+				vf.Code.Validator = &validator
+			}
+			if err = vf.Code.Validate(); err != nil {
+				return
+			}
+			if err = checkProgramRuntimes(hosts, vf); err != nil {
+				return
+			}
+		}
+		if vf.Custom != nil {
+			if err = vf.Custom.Validate(); err != nil {
+				return
+			}
+		}
+		if vf.Suite != nil {
+			if err = vf.Suite.Validate(); err != nil {
+				return
+			}
+		}
+		if vf.Lint != nil {
+			if err = vf.Lint.Validate(); err != nil {
+				return
+			}
+		}
 		var data []byte
 		data, err = json.Marshal(vf)
 		if err != nil {
@@ -149,6 +270,7 @@ func addBatch(c *gin.Context) {
 		vfs = append(vfs, &orm.Verification{
 			Name:    vf.Name,
 			Runtime: vf.Runtime,
+			Version: vf.Version,
 			Data:    string(data),
 		})
 	}
@@ -157,6 +279,9 @@ func addBatch(c *gin.Context) {
 		Name:          req.Name,
 		Describe:      req.Describe,
 		Runtime:       req.Runtime,
+		Version:       req.Version,
+		Status:        types.BatchStatusUnvalidated,
+		Deadline:      req.Deadline,
 		UserID:        userID,
 		CreatedAt:     time.Now(),
 		Verifications: vfs,
@@ -170,6 +295,29 @@ func addBatch(c *gin.Context) {
 			return
 		}
 	}
+	if req.Reference != nil {
+		// the reference solution is run after the cases are generated
+		if err = startValidation(c, batch.ID, userID, req.Reference, true); err != nil {
+			return
+		}
+		batch.Status = types.BatchStatusValidating
+	}
+	for _, vf := range vfs {
+		code := codeVerification(vf)
+		switch {
+		case code == nil:
+		case code.Generator != nil:
+			// the inputs are checked after the generation
+			if req.Reference == nil {
+				err = dispatchRequest(vf, 0, types.RequestKindGenerate)
+			}
+		case code.Validator != nil:
+			err = dispatchRequest(vf, 0, types.RequestKindCheckInputs)
+		}
+		if err != nil {
+			return
+		}
+	}
 	util.LogStruct(batch)
 
 	// test
@@ -276,9 +424,28 @@ func uploadCase(c *gin.Context) {
 	}))
 }
 
+type codeReq struct {
+	Code string `json:"code"`
+	// Files is a project submission that maps the file paths to their content
+	Files map[string]string `json:"files"`
+}
+
+// content returns the code to store, a project is zipped
+func (r *codeReq) content() (code []byte, codeType string, err error) {
+	if len(r.Files) == 0 {
+		return []byte(r.Code), "", nil
+	}
+	code, err = archive.Zip(r.Files)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return code, archive.FormatZip, nil
+}
+
 type newTaskReq struct {
-	BatchID int    `json:"batchID"`
-	Code    string `json:"code"`
+	BatchID int `json:"batchID"`
+	codeReq
 }
 
 func newTaskOfBatch(c *gin.Context) {
@@ -286,36 +453,315 @@ func newTaskOfBatch(c *gin.Context) {
 	if err := c.BindJSON(req); err != nil {
 		return
 	}
-	batch, err := db.GetBatchByIDWithVerifications(req.BatchID)
+	code, codeType, err := req.content()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, jsend.SimpleErr(err.Error()))
+		return
+	}
+
+	createTask(c, req.BatchID, code, codeType)
+}
+
+// newProjectTaskOfBatch creates a task from a zip or tar archive of a project
+func newProjectTaskOfBatch(c *gin.Context) {
+	batchID, _ := strconv.Atoi(c.PostForm("batchID"))
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, jsend.SimpleErr(err.Error()))
+		return
+	}
+	format, err := archive.DetectFormat(file.Filename)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, jsend.SimpleErr(err.Error()))
 		return
 	}
+	if file.Size > archive.MaxSize {
+		c.JSON(http.StatusBadRequest, jsend.SimpleErr("the archive is too large"))
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, jsend.SimpleErr(err.Error()))
+		return
+	}
+	defer f.Close()
+	code, err := io.ReadAll(f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, jsend.SimpleErr(err.Error()))
+		return
+	}
+	// reject broken or unsafe archives before they reach the actuators
+	if _, err = archive.Extract(code, format); err != nil {
+		c.JSON(http.StatusBadRequest, jsend.SimpleErr(err.Error()))
+		return
+	}
+
+	createTask(c, batchID, code, format)
+}
+
+func createTask(c *gin.Context, batchID int, code []byte, codeType string) {
+	batch, err := db.GetBatchByIDWithVerifications(batchID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, jsend.SimpleErr(err.Error()))
+		return
+	}
+	task, err := submitTask(c, batch, getUserIDFromReq(c), types.TaskKindSubmission, code, codeType, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, jsend.SimpleErr(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, jsend.Success(task))
+}
+
+// submitTask stores the code and sends a subtask of every verification to the actuators,
+// the cases of the verifications with a generator are generated first when generate is set
+func submitTask(ctx context.Context, batch *orm.Batch, userID int, kind string, code []byte, codeType string,
+	generate bool) (*orm.Task, error) {
+	task := &orm.Task{
+		UserID:    userID,
+		BatchID:   batch.ID,
+		Kind:      kind,
+		CodeType:  codeType,
+		CreatedAt: time.Now(),
+	}
+	if err := db.AddTask(task); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if codeType == "" {
+		err = oss.PutTextFile(ctx, storage, code, oss.GetCodePath(task.ID))
+	} else {
+		err = storage.Put(ctx, oss.GetCodeArchivePath(task.ID, codeType), bytes.NewReader(code), int64(len(code)), archiveContentType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = dispatcherTask(task, batch, generate); err != nil {
+		return nil, err
+	}
+	if kind == types.TaskKindSubmission {
+		similarityJobs.add(batch.ID)
+	}
+
+	return task, nil
+}
+
+// validateBatch runs the reference solution of the batch, the latest one is run again without a body
+func validateBatch(c *gin.Context) {
+	var err error
 	defer func() {
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, jsend.SimpleErr(err.Error()))
 		}
 	}()
-
-	task := &orm.Task{
-		UserID:    getUserIDFromReq(c),
-		BatchID:   batch.ID,
-		CreatedAt: time.Now(),
+	id, _ := strconv.Atoi(c.Param("id"))
+	batch, err := db.GetBatchByID(id)
+	if err != nil {
+		return
 	}
-	if err = db.AddTask(task); err != nil {
+	userID := getUserIDFromReq(c)
+	if batch.UserID != userID {
+		c.JSON(http.StatusForbidden, jsend.SimpleErr("only the author can validate the batch"))
+		err = nil
+		return
+	}
+	req := &codeReq{}
+	if c.Request.ContentLength > 0 {
+		if err = c.BindJSON(req); err != nil {
+			return
+		}
+	}
+	if err = startValidation(c, id, userID, req, false); err != nil {
 		return
 	}
 
-	err = ossClient.PutTextFile(c, []byte(req.Code), oss.GetCodePath(task.ID))
+	batch, err = db.GetBatchByID(id)
 	if err != nil {
 		return
 	}
+	c.JSON(http.StatusOK, jsend.Success(batch))
+}
 
-	if err = dispatcherTask(task, batch); err != nil {
+// startValidation submits the reference solution, an empty request reuses the code of the latest reference task
+func startValidation(ctx context.Context, batchID, userID int, req *codeReq, generate bool) error {
+	batch, err := db.GetBatchByIDWithVerifications(batchID)
+	if err != nil {
+		return err
+	}
+	code, codeType, err := req.content()
+	if err != nil {
+		return err
+	}
+	if len(code) == 0 {
+		if batch.ReferenceTaskID == 0 {
+			return errors.New("the batch has no reference solution")
+		}
+		var last *orm.Task
+		last, err = db.GetTaskByID(batch.ReferenceTaskID)
+		if err != nil {
+			return err
+		}
+		codeType = last.CodeType
+		codePath := oss.GetCodePath(last.ID)
+		if codeType != "" {
+			codePath = oss.GetCodeArchivePath(last.ID, codeType)
+		}
+		if code, err = storage.Get(ctx, codePath); err != nil {
+			return err
+		}
+	}
+
+	task, err := submitTask(ctx, batch, userID, types.TaskKindReference, code, codeType, generate)
+	if err != nil {
+		return err
+	}
+
+	return db.SetBatchReference(batchID, task.ID)
+}
+
+// generateCases regenerates the cases of the verifications with a generator,
+// the batch is validated again if it has a reference solution
+func generateCases(c *gin.Context) {
+	var err error
+	defer func() {
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, jsend.SimpleErr(err.Error()))
+		}
+	}()
+	id, _ := strconv.Atoi(c.Param("id"))
+	batch, err := db.GetBatchByIDWithVerifications(id)
+	if err != nil {
+		return
+	}
+	userID := getUserIDFromReq(c)
+	if batch.UserID != userID {
+		c.JSON(http.StatusForbidden, jsend.SimpleErr("only the author can generate the cases"))
+		err = nil
 		return
 	}
+	if batch.ReferenceTaskID != 0 {
+		if err = startValidation(c, id, userID, &codeReq{}, true); err != nil {
+			return
+		}
+	} else {
+		for _, vf := range batch.Verifications {
+			if !hasGenerator(vf) {
+				continue
+			}
+			if err = dispatchRequest(vf, 0, types.RequestKindGenerate); err != nil {
+				return
+			}
+		}
+	}
 
-	c.JSON(http.StatusOK, jsend.Success(task))
+	c.JSON(http.StatusOK, jsend.Success(batch))
+}
+
+type validationReport struct {
+	Status string   `json:"status"`
+	Task   *vo.Task `json:"task,omitempty"`
+	// Failures are the cases that the reference solution does not pass
+	Failures []caseFailure `json:"failures,omitempty"`
+	// InvalidInputs are the cases rejected by the input validators
+	InvalidInputs []caseFailure `json:"invalidInputs,omitempty"`
+}
+
+type caseFailure struct {
+	Verification string `json:"verification"`
+	Case         string `json:"case,omitempty"`
+	Verdict      string `json:"verdict,omitempty"`
+	Message      string `json:"message,omitempty"`
+}
+
+func getBatchValidation(c *gin.Context) {
+	var err error
+	defer func() {
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, jsend.SimpleErr(err.Error()))
+		}
+	}()
+	id, _ := strconv.Atoi(c.Param("id"))
+	batch, err := db.GetBatchByIDWithVerifications(id)
+	if err != nil {
+		return
+	}
+	if batch.UserID != getUserIDFromReq(c) {
+		c.JSON(http.StatusForbidden, jsend.SimpleErr("only the author can view the validation"))
+		err = nil
+		return
+	}
+	res := &validationReport{
+		Status: batch.Status,
+	}
+	if batch.ReferenceTaskID != 0 {
+		if res.Task, err = db.GetTaskInfoByID(batch.ReferenceTaskID); err != nil {
+			return
+		}
+		res.Failures = referenceFailures(res.Task)
+	}
+	res.InvalidInputs = invalidInputs(batch.Verifications)
+
+	c.JSON(http.StatusOK, jsend.Success(res))
+}
+
+func invalidInputs(verifications []*orm.Verification) []caseFailure {
+	var res []caseFailure
+	for _, vf := range verifications {
+		code := codeVerification(vf)
+		if code == nil || code.InputCheck == nil {
+			continue
+		}
+		if code.InputCheck.Message != "" {
+			res = append(res, caseFailure{
+				Verification: vf.Name,
+				Verdict:      string(perform.VerdictInternalError),
+				Message:      code.InputCheck.Message,
+			})
+		}
+		for _, in := range code.InputCheck.Invalid {
+			res = append(res, caseFailure{
+				Verification: vf.Name,
+				Case:         in.Case,
+				Message:      in.Message,
+			})
+		}
+	}
+
+	return res
+}
+
+func referenceFailures(task *vo.Task) []caseFailure {
+	var res []caseFailure
+	for _, s := range task.SubTasks {
+		if s.Status != types.TaskStatusFinish || s.Result == types.TaskStatusSuccess {
+			continue
+		}
+		rep := &perform.Report{}
+		if len(s.Report) == 0 || json.Unmarshal(s.Report, rep) != nil || len(rep.Cases) == 0 {
+			res = append(res, caseFailure{
+				Verification: s.VerificationName,
+				Verdict:      s.Verdict,
+				Message:      s.Message,
+			})
+			continue
+		}
+		for _, cr := range rep.Cases {
+			if cr.Pass {
+				continue
+			}
+			res = append(res, caseFailure{
+				Verification: s.VerificationName,
+				Case:         cr.Name,
+				Verdict:      string(cr.Verdict),
+				Message:      cr.Message,
+			})
+		}
+	}
+
+	return res
 }
 
 func uploadFileToOSS(ctx context.Context, file *multipart.FileHeader, uid int) (name string, err error) {
@@ -332,7 +778,7 @@ func uploadFileToOSS(ctx context.Context, file *multipart.FileHeader, uid int) (
 	}
 	defer fileData.Close()
 
-	err = ossClient.Put(ctx, path.Join(oss.GetUserTempDir(uid), uuidName),
+	err = storage.Put(ctx, path.Join(oss.GetUserTempDir(uid), uuidName),
 		fileData, file.Size, contentType)
 	if err != nil {
 		return "", err
@@ -381,10 +827,10 @@ func putCasesFromDir(ctx context.Context, dir string, uid int, ossDir string) (r
 			ossInPath := path.Join(ossDir, name)
 			ossOutPath := path.Join(ossDir, outName)
 
-			if err = ossClient.PutLocalTextFile(ctx, path.Join(dir, name), path.Join(userTempDir, ossInPath)); err != nil {
+			if err = oss.PutLocalTextFile(ctx, storage, path.Join(dir, name), path.Join(userTempDir, ossInPath)); err != nil {
 				return
 			}
-			if err = ossClient.PutLocalTextFile(ctx, path.Join(dir, outName), path.Join(userTempDir, ossOutPath)); err != nil {
+			if err = oss.PutLocalTextFile(ctx, storage, path.Join(dir, outName), path.Join(userTempDir, ossOutPath)); err != nil {
 				return
 			}
 			t := perform.TestCase{
@@ -410,10 +856,10 @@ func putCases(ctx context.Context, cases []Case, uid int, ossDir string) (res []
 		ossInPath := path.Join(ossDir, cases[i].Name+defaultCaseInFileExt)
 		ossOutPath := path.Join(ossDir, cases[i].Name+defaultCaseOutFileExt)
 
-		if err = ossClient.PutTextFile(ctx, []byte(cases[i].Input), path.Join(userTempDir, ossInPath)); err != nil {
+		if err = oss.PutTextFile(ctx, storage, []byte(cases[i].Input), path.Join(userTempDir, ossInPath)); err != nil {
 			return
 		}
-		if err = ossClient.PutTextFile(ctx, []byte(cases[i].Output), path.Join(userTempDir, ossOutPath)); err != nil {
+		if err = oss.PutTextFile(ctx, storage, []byte(cases[i].Output), path.Join(userTempDir, ossOutPath)); err != nil {
 			return
 		}
 
@@ -425,6 +871,10 @@ func putCases(ctx context.Context, cases []Case, uid int, ossDir string) (res []
 			Out: perform.File{
 				OssPath: ossOutPath,
 			},
+			Weight:     cases[i].Weight,
+			Group:      cases[i].Group,
+			Limit:      cases[i].Limit,
+			Visibility: cases[i].Visibility,
 		}
 
 		res = append(res, t)
@@ -447,6 +897,11 @@ type Case struct {
 	Name   string
 	Input  string
 	Output string
+	Weight float64
+	Group  string
+	Limit  *perform.Limit
+	// Visibility is the visibility of the case, the default is hidden
+	Visibility string
 }
 
 func moveRefFile(ctx context.Context, uid, batchID int, vf *perform.Verification) error {
@@ -475,12 +930,45 @@ func moveRefFile(ctx context.Context, uid, batchID int, vf *perform.Verification
 			}
 			vf.Code.Cases[i].Out = files[0]
 		}
+		if vf.Code.Judge != nil {
+			files, err = moveOssFiles(ctx, []perform.File{vf.Code.Judge.Source}, userTempDir, batchDir)
+			if err != nil {
+				return err
+			}
+			vf.Code.Judge.Source = files[0]
+		}
+		if g := vf.Code.Generator; g != nil {
+			files, err = moveOssFiles(ctx, []perform.File{g.Generator.Source, g.Solution.Source}, userTempDir, batchDir)
+			if err != nil {
+				return err
+			}
+			g.Generator.Source, g.Solution.Source = files[0], files[1]
+		}
+		if vf.Code.Validator != nil {
+			// the validator of the batch is shared by the verifications, so it is copied instead of moved
+			src := vf.Code.Validator.Source.OssPath
+			if err = storage.Copy(ctx, path.Join(userTempDir, src), path.Join(batchDir, src)); err != nil {
+				return err
+			}
+		}
 	} else if vf.Custom != nil {
 		files, err := moveOssFiles(ctx, vf.Custom.Files, userTempDir, batchDir)
 		if err != nil {
 			return err
 		}
 		vf.Custom.Files = files
+	} else if vf.Suite != nil {
+		files, err := moveOssFiles(ctx, vf.Suite.Files, userTempDir, batchDir)
+		if err != nil {
+			return err
+		}
+		vf.Suite.Files = files
+	} else if vf.Lint != nil {
+		files, err := moveOssFiles(ctx, vf.Lint.Files, userTempDir, batchDir)
+		if err != nil {
+			return err
+		}
+		vf.Lint.Files = files
 	}
 
 	return nil
@@ -490,7 +978,7 @@ func moveOssFiles(ctx context.Context, files []perform.File, src, dst string) ([
 	var res []perform.File
 	for _, f := range files {
 		d := path.Join(dst, f.OssPath)
-		err := ossClient.Move(ctx, path.Join(src, f.OssPath), d)
+		err := storage.Move(ctx, path.Join(src, f.OssPath), d)
 		if err != nil {
 			return nil, err
 		}
@@ -501,6 +989,34 @@ func moveOssFiles(ctx context.Context, files []perform.File, src, dst string) ([
 	return res, nil
 }
 
+// checkProgramRuntimes checks that every actuator serving the runtime of the verification can also build
+// the programs of the author, since they are built by the actuator that runs the verification
+func checkProgramRuntimes(hosts map[string][]types.Runtime, vf *perform.Verification) error {
+	for host, runtimes := range hosts {
+		if !containsRuntime(runtimes, vf.GetRuntime()) {
+			continue
+		}
+		for _, rt := range vf.Code.ProgramRuntimes(vf.GetRuntime()) {
+			if !containsRuntime(runtimes, rt) {
+				return fmt.Errorf("runtime %s of the programs of verification %s is not installed on actuator %s, "+
+					"which serves runtime %s", rt, vf.Name, host, vf.GetRuntime())
+			}
+		}
+	}
+
+	return nil
+}
+
+func containsRuntime(runtimes []types.Runtime, rt types.Runtime) bool {
+	for _, r := range runtimes {
+		if r == rt {
+			return true
+		}
+	}
+
+	return false
+}
+
 func getUserIDFromReq(c *gin.Context) int {
 	t, _ := c.Get(jwtx.IdentityKey)
 	user := t.(*jwtx.TokenUserInfo)
@@ -508,8 +1024,14 @@ func getUserIDFromReq(c *gin.Context) int {
 	return user.ID
 }
 
-func dispatcherTask(task *orm.Task, batch *orm.Batch) error {
+func dispatcherTask(task *orm.Task, batch *orm.Batch, generate bool) error {
 	for _, verification := range batch.Verifications {
+		if generate && hasGenerator(verification) {
+			if err := dispatchRequest(verification, task.ID, types.RequestKindGenerate); err != nil {
+				return err
+			}
+			continue
+		}
 		req := &types.SubTaskRequest{
 			TaskID:         task.ID,
 			VerificationID: verification.ID,
@@ -518,10 +1040,48 @@ func dispatcherTask(task *orm.Task, batch *orm.Batch) error {
 		if err != nil {
 			return err
 		}
-		if err = pubClient.Publish(verification.Runtime, data); err != nil {
+		rt := types.Runtime{
+			Lang:    verification.Runtime,
+			Version: verification.Version,
+		}
+		if err = pubClient.Publish(rt.RouteKey(), data); err != nil {
 			return err
 		}
 	}
 
 	return nil
 }
+
+// dispatchRequest sends a request of the verification other than a subtask to the actuators, such as the generation
+func dispatchRequest(verification *orm.Verification, taskID int, kind string) error {
+	data, err := json.Marshal(&types.SubTaskRequest{
+		TaskID:         taskID,
+		VerificationID: verification.ID,
+		Kind:           kind,
+	})
+	if err != nil {
+		return err
+	}
+	rt := types.Runtime{
+		Lang:    verification.Runtime,
+		Version: verification.Version,
+	}
+
+	return pubClient.Publish(rt.RouteKey(), data)
+}
+
+func hasGenerator(verification *orm.Verification) bool {
+	code := codeVerification(verification)
+
+	return code != nil && code.Generator != nil
+}
+
+// codeVerification decodes the stored verification, it is nil for the other kinds of verifications
+func codeVerification(verification *orm.Verification) *perform.CodeVerification {
+	vf := &perform.Verification{}
+	if err := json.Unmarshal([]byte(verification.Data), vf); err != nil {
+		return nil
+	}
+
+	return vf.Code
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vincent-vinf/go-jsend"
+
+	"github.com/vincent-vinf/code-validator/pkg/perform"
+	"github.com/vincent-vinf/code-validator/pkg/similarity"
+	"github.com/vincent-vinf/code-validator/pkg/types"
+	"github.com/vincent-vinf/code-validator/pkg/util/archive"
+	"github.com/vincent-vinf/code-validator/pkg/util/db"
+	"github.com/vincent-vinf/code-validator/pkg/util/oss"
+	"github.com/vincent-vinf/code-validator/pkg/vo"
+)
+
+const (
+	// the pairs below minSimilarity are not stored
+	minSimilarity = 0.2
+	// the fingerprints found in more than half of the submissions are boilerplate
+	commonShare = 0.5
+	maxPairs    = 200
+)
+
+var similarityJobs = &similarityJob{pending: make(map[int]bool)}
+
+// similarityJob recomputes the similarity of the batches that have new submissions,
+// a batch is computed at most once per interval however many submissions arrive
+type similarityJob struct {
+	mu      sync.Mutex
+	pending map[int]bool
+}
+
+func (j *similarityJob) add(batchID int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.pending[batchID] = true
+}
+
+func (j *similarityJob) run(interval time.Duration) {
+	for range time.Tick(interval) {
+		j.mu.Lock()
+		batches := j.pending
+		j.pending = make(map[int]bool)
+		j.mu.Unlock()
+
+		for id := range batches {
+			if _, err := computeSimilarity(context.Background(), id); err != nil {
+				log.Errorf("failed to compute the similarity of batch %d: %s", id, err)
+			}
+		}
+	}
+}
+
+type similarityReport struct {
+	BatchID     int                    `json:"batchID"`
+	UpdatedAt   time.Time              `json:"updatedAt"`
+	Submissions []similaritySubmission `json:"submissions"`
+	// Pairs are ranked from the most similar, A and B are the ids of the tasks
+	Pairs []similarity.Pair `json:"pairs"`
+}
+
+type similaritySubmission struct {
+	TaskID   int    `json:"taskID"`
+	UserID   int    `json:"userID"`
+	Username string `json:"username"`
+}
+
+// computeSimilarity compares the latest submissions of the users of the batch, and stores the report
+func computeSimilarity(ctx context.Context, batchID int) (*similarityReport, error) {
+	batch, err := db.GetBatchByID(batchID)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := db.ListTasks(batchID, 0)
+	if err != nil {
+		return nil, err
+	}
+	latest := make(map[int]vo.Task)
+	for _, t := range tasks {
+		if last, ok := latest[t.UserID]; !ok || t.ID > last.ID {
+			latest[t.UserID] = t
+		}
+	}
+	// the name of a single file decides its language
+	name := oss.DefaultCodeFileName
+	if spec, ok := perform.Lookup(types.Runtime{Lang: batch.Runtime, Version: batch.Version}); ok {
+		name = path.Base(spec.Source)
+	}
+
+	rep := &similarityReport{
+		BatchID:   batchID,
+		UpdatedAt: time.Now(),
+	}
+	var docs []*similarity.Document
+	for _, t := range latest {
+		files, err := submissionFiles(ctx, t.ID, t.CodeType, name)
+		if err != nil {
+			// such as a task whose upload failed, it must not block the other submissions
+			log.Warnf("skip task %d in the similarity of batch %d: %s", t.ID, batchID, err)
+			continue
+		}
+		docs = append(docs, similarity.NewDocument(t.ID, t.UserID, files, similarity.DefaultK, similarity.DefaultWindow))
+		rep.Submissions = append(rep.Submissions, similaritySubmission{
+			TaskID:   t.ID,
+			UserID:   t.UserID,
+			Username: t.Username,
+		})
+	}
+	rep.Pairs = similarity.Rank(docs, similarity.Options{
+		MinSimilarity: minSimilarity,
+		CommonShare:   commonShare,
+	})
+	if len(rep.Pairs) > maxPairs {
+		rep.Pairs = rep.Pairs[:maxPairs]
+	}
+
+	data, err := json.Marshal(rep)
+	if err != nil {
+		return nil, err
+	}
+	err = storage.Put(ctx, oss.GetSimilarityPath(batchID), bytes.NewReader(data), int64(len(data)), gin.MIMEJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	return rep, nil
+}
+
+// submissionFiles reads the code of a task, only the source files of a project are compared
+func submissionFiles(ctx context.Context, taskID int, codeType, name string) ([]similarity.File, error) {
+	if codeType == "" {
+		code, err := storage.Get(ctx, oss.GetCodePath(taskID))
+		if err != nil {
+			return nil, err
+		}
+
+		return []similarity.File{{Name: name, Content: code}}, nil
+	}
+
+	data, err := storage.Get(ctx, oss.GetCodeArchivePath(taskID, codeType))
+	if err != nil {
+		return nil, err
+	}
+	entries, err := archive.Extract(data, codeType)
+	if err != nil {
+		return nil, err
+	}
+	var res []similarity.File
+	for _, e := range entries {
+		if similarity.IsSource(e.Path) {
+			res = append(res, similarity.File{Name: e.Path, Content: e.Content})
+		}
+	}
+
+	return res, nil
+}
+
+// getBatchSimilarity returns the latest similarity report of the batch, it is computed if there is none.
+// The query min drops the pairs below it.
+func getBatchSimilarity(c *gin.Context) {
+	var err error
+	defer func() {
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, jsend.SimpleErr(err.Error()))
+		}
+	}()
+	id, ok := similarityBatch(c)
+	if !ok {
+		return
+	}
+
+	rep := &similarityReport{}
+	data, err := storage.Get(c, oss.GetSimilarityPath(id))
+	switch {
+	case oss.IsNotExist(err):
+		if rep, err = computeSimilarity(c, id); err != nil {
+			return
+		}
+	case err != nil:
+		return
+	default:
+		if err = json.Unmarshal(data, rep); err != nil {
+			return
+		}
+	}
+	if m, _ := strconv.ParseFloat(c.Query("min"), 64); m > 0 {
+		var pairs []similarity.Pair
+		for _, p := range rep.Pairs {
+			if p.Similarity >= m {
+				pairs = append(pairs, p)
+			}
+		}
+		rep.Pairs = pairs
+	}
+
+	c.JSON(http.StatusOK, jsend.Success(rep))
+}
+
+// refreshBatchSimilarity computes the similarity of the batch now
+func refreshBatchSimilarity(c *gin.Context) {
+	id, ok := similarityBatch(c)
+	if !ok {
+		return
+	}
+	rep, err := computeSimilarity(c, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, jsend.SimpleErr(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, jsend.Success(rep))
+}
+
+// similarityBatch returns the batch of the request, only its author can see the similarity of the submissions
+func similarityBatch(c *gin.Context) (int, bool) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	batch, err := db.GetBatchByID(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, jsend.SimpleErr(err.Error()))
+		return 0, false
+	}
+	if batch.UserID != getUserIDFromReq(c) {
+		c.JSON(http.StatusForbidden, jsend.SimpleErr("only the author can view the similarity"))
+		return 0, false
+	}
+
+	return id, true
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/vincent-vinf/code-validator/pkg/orm"
+	"github.com/vincent-vinf/code-validator/pkg/perform"
+	"github.com/vincent-vinf/code-validator/pkg/sandbox"
+	"github.com/vincent-vinf/code-validator/pkg/util/config"
+	"github.com/vincent-vinf/code-validator/pkg/util/db"
+	"github.com/vincent-vinf/code-validator/pkg/util/oss"
+)
+
+// rejudgeDir is the directory of the step outputs of the rejudge in the local storage
+const rejudgeDir = "rejudge"
+
+var (
+	logger = logrus.New()
+
+	configPath string
+	dir        string
+	logLimit   int
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "rejudge <task-id> <verification-id>",
+	Short: "Run a subtask again in the local sandbox, and compare the report with the stored one",
+	Long: "The verification, the code and the files of the batch are copied from the storage into a local directory, " +
+		"which is used as the storage of the run, so that the run can be repeated offline with --offline.",
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		taskID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid task id %s", args[0])
+		}
+		vfID, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid verification id %s", args[1])
+		}
+		offline, _ := cmd.Flags().GetBool("offline")
+
+		return rejudge(cmd.Context(), taskID, vfID, offline)
+	},
+}
+
+func init() {
+	rootCmd.Flags().StringVar(&configPath, "config-path", "configs/config.yaml", "")
+	rootCmd.Flags().StringVar(&dir, "dir", "rejudge", "the local directory of the files")
+	rootCmd.Flags().IntVar(&logLimit, "log-limit", 4096, "the bytes of every step log that are printed, 0 prints all")
+	rootCmd.Flags().Bool("offline", false, "reuse the files fetched by a previous rejudge without the database and the storage")
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		logger.Fatal(err)
+	}
+}
+
+// subtask is what a rejudge needs, it is saved in the local directory for the offline runs
+type subtask struct {
+	Task         *orm.Task             `json:"task"`
+	Verification *orm.Verification     `json:"verification"`
+	Report       json.RawMessage       `json:"report,omitempty"`
+	Submission   perform.Submission    `json:"submission"`
+	Data         *perform.Verification `json:"-"`
+}
+
+func rejudge(ctx context.Context, taskID, vfID int, offline bool) error {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return err
+	}
+	local, err := oss.NewLocal(dir)
+	if err != nil {
+		return err
+	}
+	manifest := path.Join(rejudgeDir, fmt.Sprintf("%d-%d.json", taskID, vfID))
+
+	var st *subtask
+	if offline {
+		st, err = loadSubtask(ctx, local, manifest)
+	} else {
+		st, err = fetch(ctx, &cfg, local, taskID, vfID, manifest)
+	}
+	if err != nil {
+		return err
+	}
+
+	perform.SetStorage(local)
+	if err = perform.LoadRuntimes(cfg.Runtimes); err != nil {
+		return err
+	}
+	perform.SetDependencyConfig(cfg.Dependency)
+	sandbox.SetCgroup(cfg.Sandbox.Cgroup)
+	perform.SetPinnedCPUs(cfg.Sandbox.CPUs)
+
+	stepOutDir := path.Join(rejudgeDir, oss.GetVerificationDir(taskID, vfID))
+	for _, name := range listOrEmpty(ctx, local, stepOutDir+"/") {
+		if err = local.Remove(ctx, name); err != nil {
+			return err
+		}
+	}
+	rep, err := perform.Perform(st.Data, st.Submission, oss.GetBatchDir(st.Task.BatchID), stepOutDir)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("== step logs ==")
+	for _, name := range listOrEmpty(ctx, local, stepOutDir+"/") {
+		data, err := local.Get(ctx, name)
+		if err != nil {
+			return err
+		}
+		if logLimit > 0 && len(data) > logLimit {
+			data = append(data[:logLimit], "\n..."...)
+		}
+		fmt.Printf("--- %s ---\n%s\n", strings.TrimPrefix(name, stepOutDir+"/"), strings.TrimRight(string(data), "\n"))
+	}
+
+	fmt.Println("== report ==")
+	fmt.Printf("%s, score %v/%v: %s\n", rep.Verdict, rep.Score, rep.MaxScore, rep.Summary())
+	for _, c := range rep.Cases {
+		fmt.Printf("case %s: %s, %.3fs, %dKB %s\n", c.Name, c.Verdict, c.Time, c.Memory, c.Message)
+	}
+
+	fmt.Println("== diff against the stored result ==")
+	if len(st.Report) == 0 {
+		fmt.Println("the subtask has no stored report")
+		return nil
+	}
+	stored := &perform.Report{}
+	if err = json.Unmarshal(st.Report, stored); err != nil {
+		return fmt.Errorf("invalid stored report: %w", err)
+	}
+	changes := perform.CompareReports(stored, rep)
+	if len(changes) == 0 {
+		fmt.Println("no differences")
+	}
+	for _, c := range changes {
+		fmt.Println(c)
+	}
+
+	return nil
+}
+
+// fetch copies the verification, the code and the files of the batch into the local storage
+func fetch(ctx context.Context, cfg *config.Config, local *oss.Local, taskID, vfID int, manifest string) (
+	*subtask, error) {
+	db.Init(cfg.Mysql)
+	defer db.Close()
+	remote, err := oss.NewStorage(cfg.Minio, cfg.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := db.GetTaskByID(taskID)
+	if err != nil {
+		return nil, err
+	}
+	vf, err := db.GetVerificationByID(vfID)
+	if err != nil {
+		return nil, err
+	}
+	if vf.BatchID != task.BatchID {
+		return nil, fmt.Errorf("verification %d does not belong to the batch %d of task %d", vfID, task.BatchID, taskID)
+	}
+	st := &subtask{
+		Task:         task,
+		Verification: vf,
+		Submission:   perform.Submission{OssPath: oss.GetCodePath(taskID)},
+	}
+	if task.CodeType != "" {
+		st.Submission = perform.Submission{OssPath: oss.GetCodeArchivePath(taskID, task.CodeType), Archive: task.CodeType}
+	}
+	info, err := db.GetTaskInfoByID(taskID)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range info.SubTasks {
+		if s.VerificationID == vfID {
+			st.Report = s.Report
+		}
+	}
+
+	// the files of all verifications of the batch are fetched, they are not all referenced by the verification
+	files, err := remote.List(ctx, oss.GetBatchDir(task.BatchID)+"/")
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, st.Submission.OssPath)
+	for _, name := range files {
+		if err = copyFile(ctx, remote, local, name); err != nil {
+			return nil, err
+		}
+	}
+	logger.Infof("fetched %d files into %s", len(files), dir)
+
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err = oss.PutTextFile(ctx, local, data, manifest); err != nil {
+		return nil, err
+	}
+
+	return st, st.decode()
+}
+
+func loadSubtask(ctx context.Context, local *oss.Local, manifest string) (*subtask, error) {
+	data, err := local.Get(ctx, manifest)
+	if err != nil {
+		return nil, fmt.Errorf("the subtask has not been fetched into %s: %w", filepath.Join(dir, manifest), err)
+	}
+	st := &subtask{}
+	if err = json.Unmarshal(data, st); err != nil {
+		return nil, err
+	}
+
+	return st, st.decode()
+}
+
+func (s *subtask) decode() error {
+	s.Data = &perform.Verification{}
+
+	return json.Unmarshal([]byte(s.Verification.Data), s.Data)
+}
+
+func copyFile(ctx context.Context, src, dst oss.Storage, name string) error {
+	r, err := src.Open(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return dst.Put(ctx, name, r, -1, oss.MIMEPlain)
+}
+
+func listOrEmpty(ctx context.Context, s oss.Storage, prefix string) []string {
+	res, _ := s.List(ctx, prefix)
+	sort.Strings(res)
+
+	return res
+}
@@ -1,18 +1,23 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"github.com/vincent-vinf/go-jsend"
 
+	"github.com/vincent-vinf/code-validator/pkg/orm"
+	"github.com/vincent-vinf/code-validator/pkg/perform"
 	"github.com/vincent-vinf/code-validator/pkg/util"
 	"github.com/vincent-vinf/code-validator/pkg/util/config"
 	"github.com/vincent-vinf/code-validator/pkg/util/db"
 	"github.com/vincent-vinf/code-validator/pkg/util/jwtx"
+	"github.com/vincent-vinf/code-validator/pkg/vo"
 )
 
 var (
@@ -31,6 +36,9 @@ func main() {
 		log.Fatal(err)
 	}
 
+	if err = perform.LoadRuntimes(cfg.Runtimes); err != nil {
+		log.Fatal(err)
+	}
 	db.Init(cfg.Mysql)
 	defer db.Close()
 
@@ -60,15 +68,64 @@ func main() {
 }
 
 func getTaskDetailByID(c *gin.Context) {
+	var err error
+	defer func() {
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, jsend.SimpleErr(err.Error()))
+		}
+	}()
 	id, _ := strconv.Atoi(c.Param("id"))
 	task, err := db.GetTaskInfoByID(id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, jsend.SimpleErr(err.Error()))
 		return
 	}
+	batch, err := db.GetBatchByID(task.BatchID)
+	if err != nil {
+		return
+	}
+	// the author of the batch sees every detail, the students only see their own tasks
+	userID := getUserIDFromReq(c)
+	if batch.UserID != userID {
+		if task.UserID != userID {
+			c.JSON(http.StatusForbidden, jsend.SimpleErr("the task belongs to another user"))
+			return
+		}
+		if err = forStudent(task, batch); err != nil {
+			return
+		}
+	}
 	c.JSON(http.StatusOK, jsend.Success(task))
 }
 
+// forStudent removes the details of the cases that the students cannot see from the reports
+func forStudent(task *vo.Task, batch *orm.Batch) error {
+	afterDeadline := batch.Deadline != nil && time.Now().After(*batch.Deadline)
+	for _, s := range task.SubTasks {
+		if len(s.Report) == 0 {
+			continue
+		}
+		rep := &perform.Report{}
+		if err := json.Unmarshal(s.Report, rep); err != nil {
+			return err
+		}
+		rep.ForStudent(afterDeadline)
+		data, err := json.Marshal(rep)
+		if err != nil {
+			return err
+		}
+		s.Report = data
+	}
+
+	return nil
+}
+
+func getUserIDFromReq(c *gin.Context) int {
+	t, _ := c.Get(jwtx.IdentityKey)
+	user := t.(*jwtx.TokenUserInfo)
+
+	return user.ID
+}
+
 func getResultList(c *gin.Context) {
 	t, _ := c.Get(jwtx.IdentityKey)
 	user := t.(*jwtx.TokenUserInfo)
@@ -84,7 +141,7 @@ func getResultList(c *gin.Context) {
 }
 
 func getRuntimeCnt(c *gin.Context) {
-	count, err := db.Runtime7dayCount()
+	count, err := db.Runtime7dayCount(perform.Langs())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, jsend.SimpleErr(err.Error()))
 		return
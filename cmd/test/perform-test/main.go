@@ -25,11 +25,11 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	ossClient, err := oss.NewClient(cfg.Minio)
+	storage, err := oss.NewStorage(cfg.Minio, cfg.Storage)
 	if err != nil {
 		log.Fatal(err)
 	}
-	perform.SetOssClient(ossClient)
+	perform.SetStorage(storage)
 	vf := &perform.Verification{
 		Name:    "123",
 		Runtime: types.PythonRuntime,
@@ -76,7 +76,7 @@ func main() {
 	}
 	fmt.Println(string(data))
 	rep, err := perform.Perform(
-		vf, "t/in2out.py", "", "")
+		vf, perform.Submission{OssPath: "t/in2out.py"}, "", "")
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,189 @@
+package checker
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Mode string
+
+const (
+	// Exact compares the bytes after removing the trailing newlines
+	Exact Mode = "exact"
+	// Tokens compares the whitespace separated tokens
+	Tokens Mode = "tokens"
+	// IgnoreCase compares the tokens case-insensitively
+	IgnoreCase Mode = "ignore-case"
+	// Float compares the tokens as numbers within the absolute or relative epsilon
+	Float Mode = "float"
+	// Unordered compares the lines regardless of their order
+	Unordered Mode = "unordered"
+	// Regex matches each line of the output against the regular expression on the same line of the answer
+	Regex Mode = "regex"
+
+	DefaultEpsilon = 1e-6
+)
+
+var modes = []Mode{Exact, Tokens, IgnoreCase, Float, Unordered, Regex}
+
+// Options selects the checker, the zero value is the exact checker
+type Options struct {
+	Mode Mode `json:"mode,omitempty"`
+	// AbsEps and RelEps are only used by Float, a number is accepted when it is within either of them
+	AbsEps float64 `json:"absEps,omitempty"`
+	RelEps float64 `json:"relEps,omitempty"`
+}
+
+func (o *Options) Validate() error {
+	if o.Mode == "" {
+		return nil
+	}
+	for _, m := range modes {
+		if o.Mode == m {
+			if o.AbsEps < 0 || o.RelEps < 0 {
+				return fmt.Errorf("epsilon cannot be negative")
+			}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unsupported checker mode %s", o.Mode)
+}
+
+// Check compares the output of the program with the answer,
+// the message explains the first difference when they do not match
+func Check(opt Options, output, answer []byte) (pass bool, message string, err error) {
+	switch opt.Mode {
+	case "", Exact:
+		pass = bytes.Equal(bytes.TrimRight(output, "\n"), bytes.TrimRight(answer, "\n"))
+		if !pass {
+			message = "output differs from the answer"
+		}
+		return
+	case Tokens:
+		return compareTokens(output, answer, func(o, a string) bool { return o == a })
+	case IgnoreCase:
+		return compareTokens(output, answer, strings.EqualFold)
+	case Float:
+		abs, rel := opt.AbsEps, opt.RelEps
+		if abs == 0 && rel == 0 {
+			abs = DefaultEpsilon
+		}
+		return compareTokens(output, answer, func(o, a string) bool {
+			return floatEqual(o, a, abs, rel)
+		})
+	case Unordered:
+		return compareUnordered(output, answer)
+	case Regex:
+		return matchRegex(output, answer)
+	default:
+		return false, "", fmt.Errorf("unsupported checker mode %s", opt.Mode)
+	}
+}
+
+func compareTokens(output, answer []byte, equal func(o, a string) bool) (bool, string, error) {
+	outTokens := strings.Fields(string(output))
+	ansTokens := strings.Fields(string(answer))
+	for i := range ansTokens {
+		if i >= len(outTokens) {
+			return false, fmt.Sprintf("expected %d tokens, got %d", len(ansTokens), len(outTokens)), nil
+		}
+		if !equal(outTokens[i], ansTokens[i]) {
+			return false, fmt.Sprintf("token %d differs, expected %q, got %q", i+1, ansTokens[i], outTokens[i]), nil
+		}
+	}
+	if len(outTokens) > len(ansTokens) {
+		return false, fmt.Sprintf("expected %d tokens, got %d", len(ansTokens), len(outTokens)), nil
+	}
+
+	return true, "", nil
+}
+
+func floatEqual(o, a string, abs, rel float64) bool {
+	if o == a {
+		return true
+	}
+	x, err := strconv.ParseFloat(o, 64)
+	if err != nil {
+		return false
+	}
+	y, err := strconv.ParseFloat(a, 64)
+	if err != nil {
+		return false
+	}
+	if math.IsNaN(x) || math.IsNaN(y) {
+		return math.IsNaN(x) && math.IsNaN(y)
+	}
+	diff := math.Abs(x - y)
+
+	return diff <= abs || diff <= rel*math.Abs(y)
+}
+
+// lines splits the data into lines without CR and trailing spaces, the trailing empty lines are dropped
+func lines(data []byte) []string {
+	res := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for i := range res {
+		res[i] = strings.TrimRight(res[i], " \t\r")
+	}
+	for len(res) > 0 && res[len(res)-1] == "" {
+		res = res[:len(res)-1]
+	}
+
+	return res
+}
+
+func compareUnordered(output, answer []byte) (bool, string, error) {
+	outLines, ansLines := lines(output), lines(answer)
+	if len(outLines) != len(ansLines) {
+		return false, fmt.Sprintf("expected %d lines, got %d", len(ansLines), len(outLines)), nil
+	}
+	sort.Strings(outLines)
+	sort.Strings(ansLines)
+	for i := range ansLines {
+		if outLines[i] != ansLines[i] {
+			return false, fmt.Sprintf("line %q is not expected", outLines[i]), nil
+		}
+	}
+
+	return true, "", nil
+}
+
+func matchRegex(output, answer []byte) (bool, string, error) {
+	outLines, patterns := lines(output), lines(answer)
+	if len(outLines) != len(patterns) {
+		return false, fmt.Sprintf("expected %d lines, got %d", len(patterns), len(outLines)), nil
+	}
+	for i, p := range patterns {
+		re, err := regexp.Compile("^(?:" + p + ")$")
+		if err != nil {
+			return false, "", fmt.Errorf("line %d of the answer is not a valid regular expression: %w", i+1, err)
+		}
+		if !re.MatchString(outLines[i]) {
+			return false, fmt.Sprintf("line %d %q does not match %q", i+1, outLines[i], p), nil
+		}
+	}
+
+	return true, "", nil
+}
+
+// Command is the shell command of the verify step, which uses the code-match binary of the actuator image
+func (o *Options) Command(output, answer string) string {
+	mode := o.Mode
+	if mode == "" {
+		mode = Exact
+	}
+	cmd := fmt.Sprintf("code-match match --mode %s", mode)
+	if o.AbsEps > 0 {
+		cmd += " --abs-eps " + strconv.FormatFloat(o.AbsEps, 'g', -1, 64)
+	}
+	if o.RelEps > 0 {
+		cmd += " --rel-eps " + strconv.FormatFloat(o.RelEps, 'g', -1, 64)
+	}
+
+	return fmt.Sprintf("%s %s %s", cmd, output, answer)
+}
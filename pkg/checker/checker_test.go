@@ -0,0 +1,50 @@
+package checker
+
+import "testing"
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		opt    Options
+		output string
+		answer string
+		want   bool
+	}{
+		{"exact", Options{}, "1 2\n\n", "1 2\n", true},
+		{"exact trailing space", Options{Mode: Exact}, "1 2 \n", "1 2\n", false},
+		{"tokens", Options{Mode: Tokens}, "1  2 \r\n3\r\n", "1 2\n3\n", true},
+		{"tokens missing", Options{Mode: Tokens}, "1 2", "1 2 3", false},
+		{"tokens extra", Options{Mode: Tokens}, "1 2 3", "1 2", false},
+		{"ignore case", Options{Mode: IgnoreCase}, "YES\n", "yes", true},
+		{"float default epsilon", Options{Mode: Float}, "0.3333333", "0.333333333", true},
+		{"float absolute", Options{Mode: Float, AbsEps: 1e-3}, "1.0015", "1.0", false},
+		{"float relative", Options{Mode: Float, RelEps: 1e-3}, "1000.5", "1000", true},
+		{"float word", Options{Mode: Float}, "ans 1.0", "ans 1", true},
+		{"float not a number", Options{Mode: Float}, "abc", "1", false},
+		{"unordered", Options{Mode: Unordered}, "b\r\na  \n", "a\nb\n", true},
+		{"unordered duplicate", Options{Mode: Unordered}, "a\na\n", "a\nb\n", false},
+		{"regex", Options{Mode: Regex}, "id: 42\nok\n", "id: \\d+\nok|fine\n", true},
+		{"regex anchored", Options{Mode: Regex}, "id: 42x", "id: \\d+", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, msg, err := Check(tt.opt, []byte(tt.output), []byte(tt.answer))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Check() = %v (%s), want %v", got, msg, tt.want)
+			}
+		})
+	}
+}
+
+func TestOptionsCommand(t *testing.T) {
+	opt := Options{Mode: Float, AbsEps: 1e-4}
+	if got, want := opt.Command("./output", "./answer"), "code-match match --mode float --abs-eps 0.0001 ./output ./answer"; got != want {
+		t.Errorf("Command() = %q, want %q", got, want)
+	}
+	if err := (&Options{Mode: "diff"}).Validate(); err == nil {
+		t.Error("Validate() expects an error")
+	}
+}
@@ -1,29 +1,46 @@
 package orm
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type Verification struct {
 	ID      int
 	BatchID int
 	Name    string
 	Runtime string
+	Version string
 	Data    string
 }
 
 type Batch struct {
-	ID            int             `json:"id,omitempty"`
-	UserID        int             `json:"userID,omitempty"`
-	Name          string          `json:"name,omitempty"`
-	Describe      string          `json:"describe,omitempty"`
-	Runtime       string          `json:"runtime,omitempty"`
+	ID       int    `json:"id,omitempty"`
+	UserID   int    `json:"userID,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Describe string `json:"describe,omitempty"`
+	Runtime  string `json:"runtime,omitempty"`
+	Version  string `json:"version,omitempty"`
+	Status   string `json:"status,omitempty"`
+	// ReferenceTaskID is the task of the latest reference solution
+	ReferenceTaskID int `json:"referenceTaskID,omitempty"`
+	// Deadline reveals the cases that are hidden until it, nil means no deadline
+	Deadline      *time.Time      `json:"deadline,omitempty"`
 	CreatedAt     time.Time       `json:"createdAt,omitempty"`
 	Verifications []*Verification `json:"verifications,omitempty"`
 }
 
 type Task struct {
-	ID        int        `json:"id,omitempty"`
-	UserID    int        `json:"userID,omitempty"`
-	BatchID   int        `json:"batchID,omitempty"`
+	ID      int `json:"id,omitempty"`
+	UserID  int `json:"userID,omitempty"`
+	BatchID int `json:"batchID,omitempty"`
+	// Kind is types.TaskKindSubmission or types.TaskKindReference
+	Kind string `json:"kind,omitempty"`
+	// CodeType is the archive format of a project submission, empty for a single file
+	CodeType string `json:"codeType,omitempty"`
+	// Score is the sum of the scores of the subtasks
+	Score     float64    `json:"score"`
+	MaxScore  float64    `json:"maxScore"`
 	CreatedAt time.Time  `json:"createdAt"`
 	SubTasks  []*SubTask `json:"subTasks,omitempty"`
 }
@@ -35,5 +52,11 @@ type SubTask struct {
 	// todo status
 	Status  string `json:"status,omitempty"`
 	Result  string `json:"result"`
+	Verdict string `json:"verdict"`
 	Message string `json:"message"`
+
+	Score    float64 `json:"score"`
+	MaxScore float64 `json:"maxScore"`
+	// Report is the perform.Report of the subtask in JSON, with the result of every case
+	Report json.RawMessage `json:"report,omitempty"`
 }
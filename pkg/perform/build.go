@@ -0,0 +1,77 @@
+package perform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vincent-vinf/code-validator/pkg/pipeline"
+)
+
+const (
+	packStepName   = "pack"
+	unpackStepName = "unpack"
+	// buildDir is the directory of the step outputs of the build in the step output dir of the verification
+	buildDir      = "build"
+	artifactsFile = "./.artifacts.tar"
+)
+
+// prebuilt reports whether the code is compiled once and its artifacts are shared by the cases
+func (s *RuntimeSpec) prebuilt() bool {
+	return s.Compile != nil && len(s.Artifacts) > 0
+}
+
+// prebuiltSteps writes the code and the artifacts of the build to the box, and runs the code with the input of the case,
+// the code is written too since the program may read the other files of a project
+func prebuiltSteps(codeRefs []pipeline.FileRef) []pipeline.Step {
+	unpack := pipeline.Step{
+		Name: unpackStepName,
+		InlineTemplate: &pipeline.Template{
+			Name: unpackStepName,
+			Cmd:  "/bin/sh",
+			Args: []string{"-c", "tar -xf " + artifactsFile},
+		},
+		FileRefs: append(append([]pipeline.FileRef(nil), codeRefs...), pipeline.FileRef{
+			DataRef: pipeline.DataRef{
+				ExternalRef: &pipeline.ExternalRef{FileName: artifactsFile},
+			},
+			Path:       artifactsFile,
+			AutoRemove: true,
+		}),
+	}
+
+	return []pipeline.Step{unpack, runStep()}
+}
+
+// build compiles the code in a box of its own and packs the artifacts of the runtime,
+// the steps of pl prepare the box like in the cases. The message is set when the code cannot be compiled.
+func build(id int, spec *RuntimeSpec, pl pipeline.Pipeline, codeRefs []pipeline.FileRef, stepOutDir string) (
+	artifacts []byte, message string, err error) {
+	pl.Steps = append(append([]pipeline.Step(nil), pl.Steps...), pipeline.Step{
+		Name:     CompileStepName,
+		Template: CompileStepName,
+		FileRefs: codeRefs,
+	}, pipeline.Step{
+		Name: packStepName,
+		InlineTemplate: &pipeline.Template{
+			Name: packStepName,
+			Cmd:  "/bin/sh",
+			Args: []string{"-c", fmt.Sprintf("tar -cf %s %s", artifactsFile, strings.Join(spec.Artifacts, " "))},
+		},
+	})
+	res, out, err := execute(id, &pl, stepOutDir, artifactsFile)
+	if err != nil {
+		return nil, "", err
+	}
+	if e, ok := res.Errs[CompileStepName]; ok {
+		return nil, compileMessage(res.Outs[CompileStepName], e), nil
+	}
+	for step, e := range res.Errs {
+		return nil, "", fmt.Errorf("the build failed on step %s: %w, out: %s", step, e, res.Outs[step])
+	}
+	artifacts, ok := out[artifactsFile]
+	if !ok {
+		return nil, "", fmt.Errorf("the build did not write %s", artifactsFile)
+	}
+
+	return artifacts, "", nil
+}
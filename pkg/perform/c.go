@@ -0,0 +1,31 @@
+package perform
+
+import (
+	"github.com/vincent-vinf/code-validator/pkg/pipeline"
+	"github.com/vincent-vinf/code-validator/pkg/types"
+)
+
+var (
+	cStandards = []string{"c89", "c99", "c11", "c17"}
+)
+
+func init() {
+	Register(&RuntimeSpec{
+		Runtime: types.Runtime{Lang: types.CRuntime},
+		Source:  "./main.c",
+		Compile: func(opt *CompileOption) (*pipeline.Template, error) {
+			args, err := gccArgs(opt, cStandards, "c11")
+			if err != nil {
+				return nil, err
+			}
+
+			return gccCompile("/usr/local/bin/gcc", args, ".c", "-lm"), nil
+		},
+		Artifacts: []string{"./main"},
+		Run: pipeline.Template{
+			Cmd: "./main",
+		},
+		TimeMultiplier: 1,
+		Requires:       []string{"/usr/local/bin/gcc"},
+	})
+}
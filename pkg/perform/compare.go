@@ -0,0 +1,82 @@
+package perform
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// the times and the memory of a case are only compared when they change by more than the share and the minimum
+	measureShare = 0.5
+	minTimeDiff  = 0.1
+	// in KB
+	minMemoryDiff = 1024
+)
+
+// Change is a difference between two reports of the same verification
+type Change struct {
+	// Case is empty for the fields of the report
+	Case  string
+	Field string
+	Old   string
+	New   string
+}
+
+func (c Change) String() string {
+	if c.Case == "" {
+		return fmt.Sprintf("%s: %s -> %s", c.Field, c.Old, c.New)
+	}
+
+	return fmt.Sprintf("case %s %s: %s -> %s", c.Case, c.Field, c.Old, c.New)
+}
+
+// CompareReports returns the differences of the verdicts and the scores of the reports and of their cases,
+// and the times and the memory of the cases that changed noticeably
+func CompareReports(old, cur *Report) []Change {
+	var res []Change
+	add := func(name, field string, o, n interface{}) {
+		if a, b := fmt.Sprint(o), fmt.Sprint(n); a != b {
+			res = append(res, Change{Case: name, Field: field, Old: a, New: b})
+		}
+	}
+	add("", "verdict", string(old.Verdict), string(cur.Verdict))
+	add("", "score", old.Score, cur.Score)
+	add("", "max score", old.MaxScore, cur.MaxScore)
+
+	cases := make(map[string]*CaseResult, len(cur.Cases))
+	for i := range cur.Cases {
+		cases[cur.Cases[i].Name] = &cur.Cases[i]
+	}
+	for i := range old.Cases {
+		o := &old.Cases[i]
+		n, ok := cases[o.Name]
+		if !ok {
+			add(o.Name, "verdict", string(o.Verdict), "missing")
+			continue
+		}
+		delete(cases, o.Name)
+		add(o.Name, "verdict", string(o.Verdict), string(n.Verdict))
+		if o.Verdict == VerdictPartial || n.Verdict == VerdictPartial {
+			add(o.Name, "partial", o.Partial, n.Partial)
+		}
+		if changed(o.Time, n.Time, minTimeDiff) {
+			add(o.Name, "time", fmt.Sprintf("%.3fs", o.Time), fmt.Sprintf("%.3fs", n.Time))
+		}
+		if changed(float64(o.Memory), float64(n.Memory), minMemoryDiff) {
+			add(o.Name, "memory", fmt.Sprintf("%dKB", o.Memory), fmt.Sprintf("%dKB", n.Memory))
+		}
+	}
+	for i := range cur.Cases {
+		if _, ok := cases[cur.Cases[i].Name]; ok {
+			add(cur.Cases[i].Name, "verdict", "missing", string(cur.Cases[i].Verdict))
+		}
+	}
+
+	return res
+}
+
+func changed(old, cur, minDiff float64) bool {
+	diff := math.Abs(old - cur)
+
+	return diff > minDiff && diff > measureShare*math.Max(old, cur)
+}
@@ -0,0 +1,37 @@
+package perform
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareReports(t *testing.T) {
+	old := &Report{Verdict: VerdictWrongAnswer, Score: 1, MaxScore: 3, Cases: []CaseResult{
+		{Name: "1", Verdict: VerdictAccepted, Time: 0.5, Memory: 10000},
+		{Name: "2", Verdict: VerdictWrongAnswer, Time: 0.01},
+		{Name: "3", Verdict: VerdictAccepted},
+	}}
+	cur := &Report{Verdict: VerdictAccepted, Score: 3, MaxScore: 3, Cases: []CaseResult{
+		{Name: "1", Verdict: VerdictAccepted, Time: 0.6, Memory: 30000},
+		{Name: "2", Verdict: VerdictAccepted, Time: 0.05},
+		{Name: "4", Verdict: VerdictAccepted},
+	}}
+	var got []string
+	for _, c := range CompareReports(old, cur) {
+		got = append(got, c.String())
+	}
+	want := []string{
+		"verdict: WA -> AC",
+		"score: 1 -> 3",
+		"case 1 memory: 10000KB -> 30000KB",
+		"case 2 verdict: WA -> AC",
+		"case 3 verdict: AC -> missing",
+		"case 4 verdict: missing -> AC",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CompareReports() = %q, want %q", got, want)
+	}
+	if changes := CompareReports(old, old); len(changes) != 0 {
+		t.Errorf("CompareReports() of the same report = %v", changes)
+	}
+}
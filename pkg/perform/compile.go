@@ -0,0 +1,73 @@
+package perform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vincent-vinf/code-validator/pkg/pipeline"
+)
+
+const (
+	defaultOptimize = "O2"
+
+	// keep compiler diagnostics within the message column of the subtask
+	maxDiagnosticsLen = 1000
+)
+
+var (
+	optimizeLevels = []string{"O0", "O1", "O2", "O3", "Os"}
+)
+
+// gccArgs returns the standard and optimisation flags shared by gcc and g++
+func gccArgs(opt *CompileOption, stds []string, defaultStd string) ([]string, error) {
+	std, optimize := defaultStd, defaultOptimize
+	if opt != nil {
+		if opt.Std != "" {
+			std = opt.Std
+		}
+		if opt.Optimize != "" {
+			optimize = opt.Optimize
+		}
+	}
+	if !contains(stds, std) {
+		return nil, fmt.Errorf("unsupported standard %s, allowed: %v", std, stds)
+	}
+	if !contains(optimizeLevels, optimize) {
+		return nil, fmt.Errorf("unsupported optimisation level %s, allowed: %v", optimize, optimizeLevels)
+	}
+
+	return []string{"-std=" + std, "-" + optimize, "-Wall"}, nil
+}
+
+// gccCompile compiles every source file with the extension into ./main, so that a project may have several files,
+// the single file of a submission is the source of the runtime
+func gccCompile(compiler string, args []string, ext string, libs ...string) *pipeline.Template {
+	cmd := fmt.Sprintf(`exec %s %s -o ./main "$@" %s`, compiler, strings.Join(args, " "), strings.Join(libs, " "))
+
+	return &pipeline.Template{
+		Cmd:  "/bin/sh",
+		Args: []string{"-c", fmt.Sprintf(`find . -name '*%s' -print0 | xargs -0 sh -c '%s' %s`, ext, cmd, compiler)},
+	}
+}
+
+func compileMessage(out []byte, err error) string {
+	if len(out) == 0 {
+		return fmt.Sprintf("compilation failed: %s", err)
+	}
+	msg := "compilation failed:\n" + string(out)
+	if len(msg) > maxDiagnosticsLen {
+		msg = msg[:maxDiagnosticsLen] + "..."
+	}
+
+	return msg
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+
+	return false
+}
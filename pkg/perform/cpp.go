@@ -0,0 +1,31 @@
+package perform
+
+import (
+	"github.com/vincent-vinf/code-validator/pkg/pipeline"
+	"github.com/vincent-vinf/code-validator/pkg/types"
+)
+
+var (
+	cppStandards = []string{"c++11", "c++14", "c++17", "c++20"}
+)
+
+func init() {
+	Register(&RuntimeSpec{
+		Runtime: types.Runtime{Lang: types.CPPRuntime},
+		Source:  "./main.cpp",
+		Compile: func(opt *CompileOption) (*pipeline.Template, error) {
+			args, err := gccArgs(opt, cppStandards, "c++17")
+			if err != nil {
+				return nil, err
+			}
+
+			return gccCompile("/usr/local/bin/g++", args, ".cpp"), nil
+		},
+		Artifacts: []string{"./main"},
+		Run: pipeline.Template{
+			Cmd: "./main",
+		},
+		TimeMultiplier: 1,
+		Requires:       []string{"/usr/local/bin/g++"},
+	})
+}
@@ -0,0 +1,323 @@
+package perform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/vincent-vinf/code-validator/pkg/pipeline"
+)
+
+const (
+	// CustomResultFile is written by a custom action with ResultFormatJSON to report its result
+	CustomResultFile = "result.json"
+	// ResultFormatJSON is the result format of the custom actions that write CustomResultFile
+	ResultFormatJSON = "json"
+	// CustomResultVersion is the latest version of CustomResult
+	CustomResultVersion = 1
+
+	legacyPassFile    = "pass"
+	legacyMessageFile = "message"
+
+	attachmentDir     = "attachments"
+	maxAttachments    = 10
+	maxAttachmentSize = 1 << 20
+)
+
+// CustomResult is the JSON result of a custom action.
+// Without a verdict it is the aggregation of the cases, or decided by pass and then by the score.
+type CustomResult struct {
+	Version int     `json:"version"`
+	Verdict Verdict `json:"verdict,omitempty"`
+	Pass    *bool   `json:"pass,omitempty"`
+	// Score is the sum of the scores of the cases by default
+	Score *float64 `json:"score,omitempty"`
+	// MaxScore is the sum of the weights of the cases by default, or 1 without cases
+	MaxScore float64 `json:"maxScore,omitempty"`
+	Message  string  `json:"message,omitempty"`
+	// MessageVisibility decides whether the students can see the message, it is hidden by default
+	MessageVisibility string       `json:"messageVisibility,omitempty"`
+	Cases             []CustomCase `json:"cases,omitempty"`
+	Attachments       []Attachment `json:"attachments,omitempty"`
+}
+
+// CustomCase is a check of a custom action, it becomes a case of the report
+type CustomCase struct {
+	Name    string  `json:"name"`
+	Verdict Verdict `json:"verdict,omitempty"`
+	Pass    *bool   `json:"pass,omitempty"`
+	// Weight of the case, the default is 1
+	Weight float64 `json:"weight,omitempty"`
+	// Partial is the fraction of the weight earned by a partially correct case
+	Partial float64 `json:"partial,omitempty"`
+	Message string  `json:"message,omitempty"`
+	// Time in seconds
+	Time float64 `json:"time,omitempty"`
+	// Memory in KB
+	Memory int `json:"memory,omitempty"`
+}
+
+// Attachment is a file written by a custom action, such as a coverage report, which is kept with the step outputs
+type Attachment struct {
+	Name string `json:"name"`
+	// Path of the file in the box, it is only used by the action
+	Path        string `json:"path,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	// OssPath is where the file is stored
+	OssPath string `json:"ossPath,omitempty"`
+	// Visibility decides whether the students can see the file, it is hidden by default
+	Visibility string `json:"visibility,omitempty"`
+}
+
+func parseCustomResult(data []byte) (*CustomResult, error) {
+	r := &CustomResult{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, err
+	}
+	if r.Version < 1 || r.Version > CustomResultVersion {
+		return nil, fmt.Errorf("unsupported version %d, the latest is %d", r.Version, CustomResultVersion)
+	}
+	if r.MaxScore < 0 {
+		return nil, fmt.Errorf("the max score cannot be negative")
+	}
+	names := make(map[string]bool, len(r.Cases))
+	for _, c := range r.Cases {
+		if c.Name == "" || names[c.Name] {
+			return nil, fmt.Errorf("the name of a case must be unique and not empty: %q", c.Name)
+		}
+		names[c.Name] = true
+		if c.Partial < 0 || c.Partial > 1 {
+			return nil, fmt.Errorf("the partial credit of case %s must be between 0 and 1", c.Name)
+		}
+		if c.Verdict != "" && !validVerdict(c.Verdict) {
+			return nil, fmt.Errorf("unknown verdict %s of case %s", c.Verdict, c.Name)
+		}
+	}
+	if r.Verdict != "" && !validVerdict(r.Verdict) {
+		return nil, fmt.Errorf("unknown verdict %s", r.Verdict)
+	}
+	if !validVisibility(r.MessageVisibility) {
+		return nil, fmt.Errorf("unknown visibility %s of the message", r.MessageVisibility)
+	}
+	for _, a := range r.Attachments {
+		if !validVisibility(a.Visibility) {
+			return nil, fmt.Errorf("unknown visibility %s of attachment %s", a.Visibility, a.Name)
+		}
+	}
+
+	return r, nil
+}
+
+func validVerdict(v Verdict) bool {
+	_, ok := verdictNames[v]
+
+	return ok
+}
+
+// verdict returns the verdict of a case, a case with only a partial credit is partially correct
+func (c *CustomCase) verdict() Verdict {
+	switch {
+	case c.Verdict != "":
+		return c.Verdict
+	case c.Pass != nil && *c.Pass:
+		return VerdictAccepted
+	case c.Partial > 0:
+		return VerdictPartial
+	default:
+		return VerdictWrongAnswer
+	}
+}
+
+// report converts the result into a report, the attachments are stored separately
+func (r *CustomResult) report() *Report {
+	rep := &Report{
+		Message:           r.Message,
+		MessageVisibility: r.MessageVisibility,
+		MaxScore:          r.MaxScore,
+	}
+	if rep.MessageVisibility == "" {
+		rep.MessageVisibility = VisibilityHidden
+	}
+	cases := make([]TestCase, 0, len(r.Cases))
+	for _, c := range r.Cases {
+		cr := CaseResult{
+			Name:    c.Name,
+			Verdict: c.verdict(),
+			Message: c.Message,
+			Time:    c.Time,
+			Memory:  c.Memory,
+		}
+		cr.Pass = cr.Verdict == VerdictAccepted
+		if cr.Verdict == VerdictPartial {
+			cr.Partial = c.Partial
+		}
+		if len(cr.Message) > maxDiagnosticsLen {
+			cr.Message = cr.Message[:maxDiagnosticsLen] + "..."
+		}
+		rep.Cases = append(rep.Cases, cr)
+		cases = append(cases, TestCase{Name: c.Name, Weight: c.Weight})
+	}
+
+	groups, casesMax := scoreCases(cases, nil, rep.Cases)
+	if rep.MaxScore == 0 {
+		rep.MaxScore = 1
+		if len(cases) > 0 {
+			rep.MaxScore = casesMax
+		}
+	}
+	var score float64
+	switch {
+	case r.Score != nil:
+		score = *r.Score
+	case len(cases) > 0:
+		// the score of the cases is scaled to the max score of the result
+		score = sumGroups(groups) * rep.MaxScore / casesMax
+	}
+
+	switch {
+	case r.Verdict != "":
+		rep.Verdict = r.Verdict
+	case len(rep.Cases) > 0:
+		rep.Verdict = aggregateVerdict(rep.Cases)
+	case r.Pass != nil:
+		rep.Verdict = VerdictWrongAnswer
+		if *r.Pass {
+			rep.Verdict = VerdictAccepted
+		}
+	case r.Score != nil && score >= rep.MaxScore:
+		rep.Verdict = VerdictAccepted
+	case r.Score != nil && score > 0:
+		rep.Verdict = VerdictPartial
+	default:
+		rep.Verdict = VerdictWrongAnswer
+	}
+	rep.Pass = rep.Verdict == VerdictAccepted
+	if r.Score == nil && len(cases) == 0 && rep.Pass {
+		score = rep.MaxScore
+	}
+	rep.Score = math.Max(0, math.Min(score, rep.MaxScore))
+
+	return rep
+}
+
+// storeAttachments reads the attachments from the box and puts them into the step output dir,
+// the attachments that cannot be stored are described in the returned messages
+func storeAttachments(attachments []Attachment, readFile func(name string) ([]byte, error), stepOutDir string) (
+	[]Attachment, []string) {
+	var (
+		res  []Attachment
+		msgs []string
+	)
+	for i, a := range attachments {
+		name := path.Base(path.Clean("/" + a.Name))
+		if i >= maxAttachments {
+			msgs = append(msgs, fmt.Sprintf("attachment %s is skipped, at most %d are kept", name, maxAttachments))
+			continue
+		}
+		if name == "/" || a.Path == "" {
+			msgs = append(msgs, fmt.Sprintf("attachment %q needs a name and a path", a.Name))
+			continue
+		}
+		data, err := readFile(a.Path)
+		if err != nil {
+			msgs = append(msgs, fmt.Sprintf("attachment %s cannot be read: %s", name, err))
+			continue
+		}
+		if len(data) > maxAttachmentSize {
+			msgs = append(msgs, fmt.Sprintf("attachment %s is larger than %d bytes", name, maxAttachmentSize))
+			continue
+		}
+		contentType := a.ContentType
+		if contentType == "" {
+			if contentType = mime.TypeByExtension(path.Ext(name)); contentType == "" {
+				contentType = http.DetectContentType(data)
+			}
+		}
+		ossPath := path.Join(stepOutDir, attachmentDir, name)
+		if err = storage.Put(context.Background(), ossPath, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+			msgs = append(msgs, fmt.Sprintf("attachment %s cannot be stored: %s", name, err))
+			continue
+		}
+		res = append(res, Attachment{Name: name, ContentType: contentType, OssPath: ossPath, Visibility: a.Visibility})
+	}
+
+	return res, msgs
+}
+
+// customReport reads the result of a custom action, result.json is only read with ResultFormatJSON,
+// so that the submission cannot report a result for a legacy action. A failed step fails the verification
+// unless the action wrote result.json.
+func customReport(res *pipeline.Result, readFile func(name string) ([]byte, error), format, stepOutDir string) *Report {
+	if format != ResultFormatJSON {
+		if rep := stepErrReport(res); rep != nil {
+			return rep
+		}
+		pass, _ := readFile(legacyPassFile)
+		msg, _ := readFile(legacyMessageFile)
+		rep := &Report{
+			Pass:              strings.TrimSpace(string(pass)) == "true",
+			Verdict:           VerdictWrongAnswer,
+			Message:           string(msg),
+			MessageVisibility: VisibilityHidden,
+			MaxScore:          1,
+		}
+		if rep.Pass {
+			rep.Verdict = VerdictAccepted
+			rep.Score = rep.MaxScore
+		}
+
+		return rep
+	}
+
+	data, err := readFile(CustomResultFile)
+	if err != nil {
+		if rep := stepErrReport(res); rep != nil {
+			return rep
+		}
+
+		return &Report{
+			Verdict:  VerdictInternalError,
+			Message:  fmt.Sprintf("the action did not write %s", CustomResultFile),
+			MaxScore: 1,
+		}
+	}
+	result, err := parseCustomResult(data)
+	if err != nil {
+		return &Report{
+			Verdict:  VerdictInternalError,
+			Message:  fmt.Sprintf("invalid %s: %s", CustomResultFile, err),
+			MaxScore: 1,
+		}
+	}
+	rep := result.report()
+	var msgs []string
+	rep.Attachments, msgs = storeAttachments(result.Attachments, readFile, stepOutDir)
+	if len(msgs) > 0 {
+		rep.Message = strings.TrimSpace(rep.Message + "\n" + strings.Join(msgs, "\n"))
+	}
+
+	return rep
+}
+
+// stepErrReport returns the wrong answer of the failed steps, or nil if all steps succeeded
+func stepErrReport(res *pipeline.Result) *Report {
+	if len(res.Errs) == 0 {
+		return nil
+	}
+	var msgs []string
+	for step, e := range res.Errs {
+		msgs = append(msgs, fmt.Sprintf("step %s error: %s.", step, e))
+	}
+
+	return &Report{
+		Verdict:  VerdictWrongAnswer,
+		Message:  strings.Join(msgs, "\n"),
+		MaxScore: 1,
+	}
+}
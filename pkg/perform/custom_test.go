@@ -0,0 +1,134 @@
+package perform
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/vincent-vinf/code-validator/pkg/pipeline"
+	"github.com/vincent-vinf/code-validator/pkg/util/oss"
+)
+
+func TestCustomReport(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		files   map[string]string
+		errs    map[string]error
+		verdict Verdict
+		score   float64
+		max     float64
+		cases   int
+	}{
+		{
+			name:   "cases",
+			format: ResultFormatJSON,
+			files: map[string]string{CustomResultFile: `{"version": 1, "maxScore": 10, "cases": [
+				{"name": "style", "pass": true},
+				{"name": "api", "weight": 2, "partial": 0.5, "message": "2 of 4 endpoints"},
+				{"name": "docs", "verdict": "WA"}]}`},
+			verdict: VerdictPartial,
+			score:   5,
+			max:     10,
+			cases:   3,
+		},
+		{
+			name:    "score",
+			format:  ResultFormatJSON,
+			files:   map[string]string{CustomResultFile: `{"version": 1, "score": 3, "maxScore": 4}`},
+			verdict: VerdictPartial,
+			score:   3,
+			max:     4,
+		},
+		{
+			name:    "verdict",
+			format:  ResultFormatJSON,
+			files:   map[string]string{CustomResultFile: `{"version": 1, "verdict": "AC"}`},
+			verdict: VerdictAccepted,
+			score:   1,
+			max:     1,
+		},
+		{
+			name:    "unsupported version",
+			format:  ResultFormatJSON,
+			files:   map[string]string{CustomResultFile: `{"version": 2, "pass": true}`},
+			verdict: VerdictInternalError,
+			max:     1,
+		},
+		{
+			name:    "legacy",
+			files:   map[string]string{legacyPassFile: "true\n", legacyMessageFile: "ok"},
+			verdict: VerdictAccepted,
+			score:   1,
+			max:     1,
+		},
+		{
+			name:    "legacy ignores result.json",
+			files:   map[string]string{CustomResultFile: `{"version": 1, "pass": true}`},
+			verdict: VerdictWrongAnswer,
+			max:     1,
+		},
+		{
+			name:    "missing result.json",
+			format:  ResultFormatJSON,
+			files:   map[string]string{legacyPassFile: "true"},
+			verdict: VerdictInternalError,
+			max:     1,
+		},
+		{
+			name:    "legacy step error",
+			files:   map[string]string{legacyPassFile: "true"},
+			errs:    map[string]error{"check": errors.New("exit status 1")},
+			verdict: VerdictWrongAnswer,
+			max:     1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readFile := func(name string) ([]byte, error) {
+				if data, ok := tt.files[name]; ok {
+					return []byte(data), nil
+				}
+				return nil, errors.New("no such file")
+			}
+			rep := customReport(&pipeline.Result{Errs: tt.errs}, readFile, tt.format, "out")
+			if rep.Verdict != tt.verdict || rep.Score != tt.score || rep.MaxScore != tt.max || len(rep.Cases) != tt.cases {
+				t.Errorf("customReport() = %+v", rep)
+			}
+			if rep.Pass != (tt.verdict == VerdictAccepted) {
+				t.Errorf("Pass = %v with verdict %s", rep.Pass, rep.Verdict)
+			}
+		})
+	}
+}
+
+func TestStoreAttachments(t *testing.T) {
+	local, err := oss.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetStorage(local)
+	defer SetStorage(nil)
+
+	box := map[string]string{"./htmlcov/index.html": "<html></html>"}
+	readFile := func(name string) ([]byte, error) {
+		if data, ok := box[name]; ok {
+			return []byte(data), nil
+		}
+		return nil, errors.New("no such file")
+	}
+	res, msgs := storeAttachments([]Attachment{
+		{Name: "../coverage.html", Path: "./htmlcov/index.html"},
+		{Name: "missing.txt", Path: "./missing.txt"},
+	}, readFile, "task/1/verification/2")
+	if len(res) != 1 || len(msgs) != 1 {
+		t.Fatalf("storeAttachments() = %+v, %v", res, msgs)
+	}
+	if res[0].OssPath != "task/1/verification/2/attachments/coverage.html" || !strings.HasPrefix(res[0].ContentType, "text/html") {
+		t.Errorf("attachment = %+v", res[0])
+	}
+	if data, err := local.Get(context.Background(), res[0].OssPath); err != nil || string(data) != box["./htmlcov/index.html"] {
+		t.Errorf("the stored attachment is %q, %v", data, err)
+	}
+}
@@ -0,0 +1,182 @@
+package perform
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/vincent-vinf/code-validator/pkg/pipeline"
+	"github.com/vincent-vinf/code-validator/pkg/sandbox"
+	"github.com/vincent-vinf/code-validator/pkg/util/config"
+)
+
+const (
+	DependencyStepName = "dependency"
+
+	// depsMountPoint is where the installed environment is mounted in the box
+	depsMountPoint = "/deps"
+)
+
+var (
+	depsConfig config.Dependency
+)
+
+// DependencySpec installs the dependencies of a project from a local package cache
+type DependencySpec struct {
+	// Lockfile is the file in the root of the project that declares the dependencies
+	Lockfile string
+	// CacheDir is where the package cache is mounted in the box
+	CacheDir string
+	// Install installs the packages into EnvDir, it runs without network
+	Install pipeline.Template
+	// EnvDir is the directory in the box that the packages are installed to
+	EnvDir string
+	// Env is added to the run template, so that the code finds the packages mounted at depsMountPoint
+	Env map[string]string
+}
+
+// SetDependencyConfig enables the dependency phase for the runtimes with a package cache
+func SetDependencyConfig(cfg config.Dependency) {
+	depsConfig = cfg
+}
+
+// findLockfile returns the lockfile of the project, or nil if it does not declare dependencies
+func findLockfile(spec *RuntimeSpec, files []pipeline.File, refs []pipeline.FileRef) []byte {
+	if spec.Deps == nil {
+		return nil
+	}
+	for i := range refs {
+		if refs[i].Path != "./"+spec.Deps.Lockfile {
+			continue
+		}
+		for j := range files {
+			if files[j].Name == refs[i].ExternalRef.FileName {
+				return files[j].Content
+			}
+		}
+	}
+
+	return nil
+}
+
+// installDependencies returns the host directory of the environment installed from the lockfile.
+// The environments are cached by the hash of the lockfile, the output of the installation is returned on failure.
+func installDependencies(spec *RuntimeSpec, lockfile []byte) (envDir string, out []byte, err error) {
+	pkgDir := depsConfig.PackageDirs[spec.Lang]
+	if depsConfig.EnvCacheDir == "" || pkgDir == "" {
+		return "", nil, fmt.Errorf("installing dependencies is not enabled for runtime %s", spec.Runtime)
+	}
+	sum := sha256.Sum256(lockfile)
+	envDir = filepath.Join(depsConfig.EnvCacheDir, spec.RouteKey(), hex.EncodeToString(sum[:]))
+	if _, err = os.Stat(envDir); err == nil {
+		return envDir, nil, nil
+	}
+
+	id, err := idDispatcher.Get()
+	if err != nil {
+		return "", nil, fmt.Errorf("too many validations running at the same time: %w", err)
+	}
+	defer idDispatcher.Release(id)
+	executor, err := pipeline.NewExecutor(id)
+	if err != nil {
+		return "", nil, err
+	}
+	defer func(executor *pipeline.Executor) {
+		if e := executor.Clean(); e != nil {
+			err = e
+		}
+	}(executor)
+
+	install := spec.Deps.Install
+	install.Name = DependencyStepName
+	res, err := executor.Exec(pipeline.Pipeline{
+		Steps: []pipeline.Step{
+			{
+				Name:     DependencyStepName,
+				Template: DependencyStepName,
+				FileRefs: []pipeline.FileRef{
+					{
+						DataRef: pipeline.DataRef{
+							ExternalRef: &pipeline.ExternalRef{FileName: spec.Deps.Lockfile},
+						},
+						Path: "./" + spec.Deps.Lockfile,
+					},
+				},
+				Limit: &pipeline.Limit{EnableNetWork: false},
+			},
+		},
+		Templates: []pipeline.Template{install},
+		Files: []pipeline.File{
+			{Name: spec.Deps.Lockfile, Content: lockfile},
+		},
+		Mounts: []sandbox.Dir{
+			{Inside: spec.Deps.CacheDir, Outside: pkgDir},
+		},
+	})
+	if err != nil {
+		var stepErr *pipeline.StepError
+		if errors.As(err, &stepErr) {
+			return "", res.Outs[DependencyStepName], err
+		}
+
+		return "", nil, err
+	}
+
+	// install into a temporary directory first, so that a half copied environment is never used
+	if err = os.MkdirAll(filepath.Dir(envDir), 0755); err != nil {
+		return "", nil, err
+	}
+	tmp := envDir + ".tmp-" + strconv.Itoa(id)
+	_ = os.RemoveAll(tmp)
+	if err = executor.CopyOut(spec.Deps.EnvDir, tmp); err != nil {
+		return "", nil, err
+	}
+	if err = os.Rename(tmp, envDir); err != nil {
+		_ = os.RemoveAll(tmp)
+		// another subtask has installed the same environment meanwhile
+		if _, e := os.Stat(envDir); e == nil {
+			return envDir, nil, nil
+		}
+
+		return "", nil, err
+	}
+
+	return envDir, nil, nil
+}
+
+// withDependencyEnv adds the environment of the dependencies to the run template
+func withDependencyEnv(spec *RuntimeSpec, templates []pipeline.Template) {
+	for i := range templates {
+		if templates[i].Name != RunStepName {
+			continue
+		}
+		env := make(map[string]string, len(templates[i].Env)+len(spec.Deps.Env))
+		for k, v := range templates[i].Env {
+			env[k] = v
+		}
+		for k, v := range spec.Deps.Env {
+			env[k] = v
+		}
+		templates[i].Env = env
+	}
+}
+
+func dependencyMessage(out []byte, err error) string {
+	msg := fmt.Sprintf("dependency installation failed: %s", err)
+	if len(out) > 0 {
+		msg = "dependency installation failed:\n" + string(out)
+	}
+	if len(msg) > maxDiagnosticsLen {
+		msg = msg[:maxDiagnosticsLen] + "..."
+	}
+
+	return msg
+}
+
+func depsMount(envDir string) sandbox.Dir {
+	return sandbox.Dir{Inside: depsMountPoint, Outside: envDir}
+}
@@ -0,0 +1,112 @@
+package perform
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// diffContext is the number of lines shown around the first difference
+	diffContext = 2
+	// maxDiffLineLen keeps a single long line from filling the report
+	maxDiffLineLen = 200
+)
+
+// Diff is an excerpt of the first difference between the expected and the actual output
+type Diff struct {
+	// Line and Column of the first difference, starting from 1
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	// FirstLine and FirstColumn are the position of the excerpts in the outputs
+	FirstLine   int      `json:"firstLine"`
+	FirstColumn int      `json:"firstColumn"`
+	Expected    []string `json:"expected"`
+	Actual      []string `json:"actual"`
+	// Truncated is set when the excerpts do not contain whole lines or outputs
+	Truncated bool `json:"truncated"`
+}
+
+// diffLines returns nil when the outputs are the same line by line
+func diffLines(expected, actual []byte) *Diff {
+	exp, act := splitLines(expected), splitLines(actual)
+	i := 0
+	for i < len(exp) && i < len(act) && exp[i] == act[i] {
+		i++
+	}
+	if i == len(exp) && i == len(act) {
+		return nil
+	}
+
+	d := &Diff{
+		Line:   i + 1,
+		Column: 1,
+	}
+	if i < len(exp) && i < len(act) {
+		d.Column = firstDiffColumn(exp[i], act[i])
+	}
+	start := i - diffContext
+	if start < 0 {
+		start = 0
+	}
+	// the excerpts of a long line start before the difference
+	offset := 0
+	if d.Column > maxDiffLineLen {
+		offset = d.Column - 1 - maxDiffLineLen/2
+	}
+	d.FirstLine, d.FirstColumn = start+1, offset+1
+	var truncated bool
+	d.Expected, truncated = excerpt(exp, start, i+diffContext+1, offset)
+	d.Truncated = d.Truncated || truncated
+	d.Actual, truncated = excerpt(act, start, i+diffContext+1, offset)
+	d.Truncated = d.Truncated || truncated
+
+	return d
+}
+
+func splitLines(data []byte) []string {
+	s := strings.TrimRight(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, "\n")
+}
+
+// firstDiffColumn counts in runes, the column after the shorter line when one is the prefix of the other
+func firstDiffColumn(a, b string) int {
+	col := 1
+	for len(a) > 0 && len(b) > 0 {
+		ra, na := utf8.DecodeRuneInString(a)
+		rb, nb := utf8.DecodeRuneInString(b)
+		if ra != rb {
+			break
+		}
+		a, b = a[na:], b[nb:]
+		col++
+	}
+
+	return col
+}
+
+// excerpt returns the lines in [start, end), each of them starts from the rune at offset
+func excerpt(lines []string, start, end, offset int) (res []string, truncated bool) {
+	if end > len(lines) {
+		end = len(lines)
+	}
+	truncated = start > 0 || end < len(lines) || offset > 0
+	for i := start; i < end; i++ {
+		runes := []rune(lines[i])
+		if offset >= len(runes) {
+			res = append(res, "")
+			continue
+		}
+		runes = runes[offset:]
+		if len(runes) > maxDiffLineLen {
+			runes = runes[:maxDiffLineLen]
+			truncated = true
+		}
+		res = append(res, string(runes))
+	}
+
+	return res, truncated
+}
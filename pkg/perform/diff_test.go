@@ -0,0 +1,44 @@
+package perform
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	if d := diffLines([]byte("1\n2\n"), []byte("1\r\n2")); d != nil {
+		t.Errorf("diffLines() = %+v, want nil", d)
+	}
+
+	d := diffLines([]byte("a\nb\nc\nd\ne\nf\n"), []byte("a\nb\nc\nx\ne\n"))
+	want := &Diff{
+		Line:        4,
+		Column:      1,
+		FirstLine:   2,
+		FirstColumn: 1,
+		Expected:    []string{"b", "c", "d", "e", "f"},
+		Actual:      []string{"b", "c", "x", "e"},
+		Truncated:   true,
+	}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("diffLines() = %+v, want %+v", d, want)
+	}
+
+	d = diffLines([]byte("hello"), []byte("help"))
+	if d.Line != 1 || d.Column != 4 || d.Truncated {
+		t.Errorf("diffLines() = %+v, want line 1 column 4", d)
+	}
+
+	d = diffLines([]byte("1\n2\n"), []byte("1\n"))
+	if d.Line != 2 || len(d.Actual) != 1 {
+		t.Errorf("diffLines() = %+v, want line 2", d)
+	}
+
+	long := strings.Repeat("a", 1000)
+	d = diffLines([]byte(long+"b"), []byte(long+"c"))
+	if d.Column != 1001 || !d.Truncated || len(d.Expected[0]) > maxDiffLineLen ||
+		!strings.HasSuffix(d.Expected[0], "b") {
+		t.Errorf("diffLines() = %+v, want an excerpt around column 1001", d)
+	}
+}
@@ -0,0 +1,195 @@
+package perform
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vincent-vinf/code-validator/pkg/util/oss"
+)
+
+const (
+	// GeneratedDir is the directory of the generated cases in the directory of the batch
+	GeneratedDir     = "generated"
+	generateStepName = "generate"
+	solveStepName    = "solve"
+	manifestFile     = "manifest.json"
+)
+
+// CaseGenerator produces the cases of a verification, the input of a case is the output of the generator
+// run with the arguments of the case, and the answer is the output of the solution run with the input
+type CaseGenerator struct {
+	Generator Program `json:"generator"`
+	Solution  Program `json:"solution"`
+	// Limit of the generator and the solution, the default is the limit of the verification
+	Limit *Limit          `json:"limit,omitempty"`
+	Cases []GeneratedCase `json:"cases"`
+	// Generated records the latest generation, it is nil before the cases are generated
+	Generated *Generation `json:"generated,omitempty"`
+}
+
+// GeneratedCase is a case without In and Out, which are filled in by the generation
+type GeneratedCase struct {
+	TestCase
+	// Args are passed to the generator, followed by the seed when it is set
+	Args []string `json:"args,omitempty"`
+	Seed *int64   `json:"seed,omitempty"`
+}
+
+func (gc *GeneratedCase) args() []string {
+	args := append([]string(nil), gc.Args...)
+	if gc.Seed != nil {
+		args = append(args, strconv.FormatInt(*gc.Seed, 10))
+	}
+
+	return args
+}
+
+// Generation records the programs that generated the cases
+type Generation struct {
+	GeneratorHash string    `json:"generatorHash,omitempty"`
+	SolutionHash  string    `json:"solutionHash,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+	// Message is the reason of a failed generation, the cases of the previous generation are kept
+	Message string `json:"message,omitempty"`
+}
+
+func (g *CaseGenerator) validate() error {
+	if g.Generator.Source.OssPath == "" || g.Solution.Source.OssPath == "" {
+		return fmt.Errorf("the generator and the solution cannot be empty")
+	}
+	if len(g.Cases) == 0 {
+		return fmt.Errorf("the generator has no cases")
+	}
+	names := make(map[string]bool, len(g.Cases))
+	for _, gc := range g.Cases {
+		if gc.Name == "" || strings.ContainsAny(gc.Name, `/\`) || gc.Name == "." || gc.Name == ".." {
+			return fmt.Errorf("invalid name of the generated case %q", gc.Name)
+		}
+		if names[gc.Name] {
+			return fmt.Errorf("duplicate generated case %s", gc.Name)
+		}
+		names[gc.Name] = true
+	}
+
+	return nil
+}
+
+// testCases returns the cases stored by the generation of the verification
+func (g *CaseGenerator) testCases(vfName string) []TestCase {
+	res := make([]TestCase, 0, len(g.Cases))
+	for _, gc := range g.Cases {
+		tc := gc.TestCase
+		tc.In = File{OssPath: path.Join(GeneratedDir, vfName, gc.Name+".in")}
+		tc.Out = File{OssPath: path.Join(GeneratedDir, vfName, gc.Name+".out")}
+		res = append(res, tc)
+	}
+
+	return res
+}
+
+// Generate produces the cases of the verification and stores them in srcDir,
+// a failure of the programs is returned as the Message of the generation
+func Generate(vf *Verification, srcDir string) ([]TestCase, *Generation, error) {
+	if vf.Code == nil || vf.Code.Generator == nil {
+		return nil, nil, fmt.Errorf("verification %s has no case generator", vf.Name)
+	}
+	g := vf.Code.Generator
+	if err := g.validate(); err != nil {
+		return nil, nil, err
+	}
+	genSource, err := ReadOSSFile(path.Join(srcDir, g.Generator.Source.OssPath))
+	if err != nil {
+		return nil, nil, err
+	}
+	solSource, err := ReadOSSFile(path.Join(srcDir, g.Solution.Source.OssPath))
+	if err != nil {
+		return nil, nil, err
+	}
+	gen := &Generation{
+		GeneratorHash: sourceHash(genSource),
+		SolutionHash:  sourceHash(solSource),
+		CreatedAt:     time.Now(),
+	}
+
+	id, err := idDispatcher.Get()
+	if err != nil {
+		return nil, nil, fmt.Errorf("too many validations running at the same time: %w", err)
+	}
+	defer idDispatcher.Release(id)
+
+	dir := path.Join(srcDir, GeneratedDir, vf.Name)
+	limit := vf.Code.Limit.merge(g.Limit)
+	runs := make([]programRun, len(g.Cases))
+	for i := range g.Cases {
+		runs[i] = programRun{Name: g.Cases[i].Name, Args: g.Cases[i].args()}
+	}
+	results, msg, err := runProgram(id, &g.Generator, vf.GetRuntime(), genSource, runs, limit,
+		path.Join(dir, "log", generateStepName), generateStepName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if msg == "" {
+		msg = failedRun(runs, results)
+	}
+	if msg != "" {
+		gen.Message = "generator " + msg
+		return nil, gen, nil
+	}
+	inputs := make([][]byte, len(runs))
+	for i := range runs {
+		inputs[i] = results[i].Out
+		runs[i] = programRun{Name: g.Cases[i].Name, Input: inputs[i]}
+	}
+	results, msg, err = runProgram(id, &g.Solution, vf.GetRuntime(), solSource, runs, limit,
+		path.Join(dir, "log", solveStepName), solveStepName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if msg == "" {
+		msg = failedRun(runs, results)
+	}
+	if msg != "" {
+		gen.Message = "solution " + msg
+		return nil, gen, nil
+	}
+	answers := make([][]byte, len(runs))
+	for i := range results {
+		answers[i] = results[i].Out
+	}
+
+	cases := g.testCases(vf.Name)
+	for i, tc := range cases {
+		if err = oss.PutTextFile(context.Background(), storage, inputs[i], path.Join(srcDir, tc.In.OssPath)); err != nil {
+			return nil, nil, err
+		}
+		if err = oss.PutTextFile(context.Background(), storage, answers[i], path.Join(srcDir, tc.Out.OssPath)); err != nil {
+			return nil, nil, err
+		}
+	}
+	// the manifest keeps the parameters next to the files, so that they can be traced without the batch
+	manifest, err := json.MarshalIndent(struct {
+		*Generation
+		Cases []GeneratedCase `json:"cases"`
+	}{gen, g.Cases}, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = oss.PutTextFile(context.Background(), storage, manifest, path.Join(dir, manifestFile)); err != nil {
+		return nil, nil, err
+	}
+
+	return cases, gen, nil
+}
+
+func sourceHash(source []byte) string {
+	h := sha256.Sum256(source)
+
+	return hex.EncodeToString(h[:])
+}
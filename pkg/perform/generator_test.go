@@ -0,0 +1,41 @@
+package perform
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCaseGenerator(t *testing.T) {
+	seed := int64(42)
+	g := &CaseGenerator{
+		Generator: Program{Source: File{OssPath: "gen.cpp"}},
+		Solution:  Program{Source: File{OssPath: "sol.cpp"}},
+		Cases: []GeneratedCase{
+			{TestCase: TestCase{Name: "small", Group: "a"}, Args: []string{"-n", "10"}, Seed: &seed},
+			{TestCase: TestCase{Name: "large", Weight: 2}, Args: []string{"-n", "100000"}},
+		},
+	}
+	if err := g.validate(); err != nil {
+		t.Fatalf("validate() = %v", err)
+	}
+	if args := g.Cases[0].args(); !reflect.DeepEqual(args, []string{"-n", "10", "42"}) {
+		t.Errorf("args() = %v", args)
+	}
+
+	cases := g.testCases("vf")
+	if len(cases) != 2 || cases[0].In.OssPath != "generated/vf/small.in" || cases[1].Out.OssPath != "generated/vf/large.out" {
+		t.Errorf("testCases() = %+v", cases)
+	}
+	if cases[0].Group != "a" || cases[1].Weight != 2 {
+		t.Errorf("testCases() lost the fields of the cases: %+v", cases)
+	}
+
+	g.Cases[1].Name = "small"
+	if err := g.validate(); err == nil {
+		t.Error("validate() accepted duplicate cases")
+	}
+	g.Cases[1].Name = "../x"
+	if err := g.validate(); err == nil {
+		t.Error("validate() accepted a case name with a path")
+	}
+}
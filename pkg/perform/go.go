@@ -1,5 +1,3 @@
-//go:build golang
-
 package perform
 
 import (
@@ -7,40 +5,23 @@ import (
 	"github.com/vincent-vinf/code-validator/pkg/types"
 )
 
-const (
-	Runtime = types.GolangRuntime
-)
+// goModInit creates the module of a submission without go.mod, such as a single file
+const goModInit = "[ -f go.mod ] || /usr/local/go/bin/go mod init code.vinf.top/user/code"
 
-func GetCodeTemplates() []pipeline.Template {
-	return []pipeline.Template{
-		{
-			Name: RunStepName,
-			Cmd:  "sh",
+func init() {
+	Register(&RuntimeSpec{
+		Runtime: types.Runtime{Lang: types.GolangRuntime},
+		Source:  "./main.go",
+		Run: pipeline.Template{
+			Cmd: "sh",
 			Args: []string{
 				"-c",
-				"/usr/local/go/bin/go mod init code.vinf.top/user/code && go run ./main.go",
-			},
-		},
-	}
-}
-
-func GetCodeSteps() []pipeline.Step {
-	return []pipeline.Step{
-		{
-			Name:     RunStepName,
-			Template: RunStepName,
-			LogMate:  true,
-			InputRef: &pipeline.DataRef{
-				ExternalRef: &pipeline.ExternalRef{FileName: "input"},
-			},
-			FileRefs: []pipeline.FileRef{
-				{
-					DataRef: pipeline.DataRef{
-						ExternalRef: &pipeline.ExternalRef{FileName: "code"},
-					},
-					Path: "./main.go",
-				},
+				// the package of the entry point is run, so that it may have several files
+				goModInit + ` && /usr/local/go/bin/go run "$(dirname ./main.go)"`,
 			},
 		},
-	}
+		// go run also compiles the code
+		TimeMultiplier: 3,
+		Requires:       []string{"/usr/local/go/bin/go"},
+	})
 }
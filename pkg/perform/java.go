@@ -0,0 +1,51 @@
+package perform
+
+import (
+	"github.com/vincent-vinf/code-validator/pkg/pipeline"
+	"github.com/vincent-vinf/code-validator/pkg/types"
+)
+
+const (
+	javaMainClassFile = "./classes/main-class"
+	// javaMemoryEnv is the memory limit in KB, the JVM reserves more address space than the limit of the sandbox
+	// without control groups, so the limit is the max heap instead
+	javaMemoryEnv = "MEMORY_LIMIT_KB"
+)
+
+// javaCompileScript renames the source after its public class, since javac requires the file name to match,
+// and records the class to run: Main if the code has one, otherwise the public class.
+const javaCompileScript = `set -e
+cls=$(grep -oE 'public[[:space:]]+((final|abstract)[[:space:]]+)*class[[:space:]]+[A-Za-z_$][A-Za-z0-9_$]*' ./Main.java | head -n 1 | awk '{print $NF}')
+pkg=$(sed -n 's/^[[:space:]]*package[[:space:]]*\([A-Za-z0-9_.]*\)[[:space:]]*;.*/\1/p' ./Main.java | head -n 1)
+src=./Main.java
+if [ -n "$cls" ] && [ "$cls" != Main ]; then src=./$cls.java; mv ./Main.java $src; fi
+/usr/bin/javac -encoding UTF-8 -d ./classes $src
+main=Main
+if [ ! -f ./classes/$(echo "$pkg" | tr . /)/Main.class ] && [ -n "$cls" ]; then main=$cls; fi
+echo ${pkg:+$pkg.}$main > ` + javaMainClassFile
+
+func init() {
+	Register(&RuntimeSpec{
+		Runtime: types.Runtime{Lang: types.JavaRuntime},
+		Source:  "./Main.java",
+		Compile: func(*CompileOption) (*pipeline.Template, error) {
+			return &pipeline.Template{
+				Cmd:  "/bin/sh",
+				Args: []string{"-c", javaCompileScript},
+			}, nil
+		},
+		Artifacts: []string{"./classes"},
+		Run: pipeline.Template{
+			Cmd: "/bin/sh",
+			Args: []string{
+				"-c",
+				"exec /usr/bin/java ${" + javaMemoryEnv + ":+-Xmx${" + javaMemoryEnv + "}k} -Xss64m -cp ./classes $(cat " +
+					javaMainClassFile + ")",
+			},
+		},
+		MemoryEnv: javaMemoryEnv,
+		// the JVM startup takes a lot of cpu time
+		TimeMultiplier: 2,
+		Requires:       []string{"/usr/bin/javac"},
+	})
+}
@@ -1,5 +1,3 @@
-//go:build javascript
-
 package perform
 
 import (
@@ -7,39 +5,33 @@ import (
 	"github.com/vincent-vinf/code-validator/pkg/types"
 )
 
-const (
-	Runtime = types.JavaScriptRuntime
-)
-
-func GetCodeTemplates() []pipeline.Template {
-	return []pipeline.Template{
-		{
-			Name: RunStepName,
-			Cmd:  "/usr/local/bin/node",
+func init() {
+	Register(&RuntimeSpec{
+		Runtime: types.Runtime{Lang: types.JavaScriptRuntime},
+		Source:  "./index.js",
+		Run: pipeline.Template{
+			Cmd: "/usr/local/bin/node",
 			Args: []string{
 				"./index.js",
 			},
 		},
-	}
-}
-
-func GetCodeSteps() []pipeline.Step {
-	return []pipeline.Step{
-		{
-			Name:     RunStepName,
-			Template: RunStepName,
-			LogMate:  true,
-			InputRef: &pipeline.DataRef{
-				ExternalRef: &pipeline.ExternalRef{FileName: "input"},
-			},
-			FileRefs: []pipeline.FileRef{
-				{
-					DataRef: pipeline.DataRef{
-						ExternalRef: &pipeline.ExternalRef{FileName: "code"},
-					},
-					Path: "./index.js",
+		TimeMultiplier: 2,
+		Requires:       []string{"/usr/local/bin/node"},
+		Deps: &DependencySpec{
+			Lockfile: "package.json",
+			CacheDir: "/npm-cache",
+			Install: pipeline.Template{
+				Cmd: "/usr/local/bin/npm",
+				Args: []string{
+					"install", "--offline", "--cache", "/npm-cache", "--ignore-scripts",
+					"--no-audit", "--no-fund", "--no-package-lock",
 				},
 			},
+			EnvDir: "./node_modules",
+			// only require resolves NODE_PATH, ES modules have to import the packages by path
+			Env: map[string]string{
+				"NODE_PATH": depsMountPoint,
+			},
 		},
-	}
+	})
 }
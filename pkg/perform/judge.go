@@ -0,0 +1,200 @@
+package perform
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/vincent-vinf/code-validator/pkg/pipeline"
+	"github.com/vincent-vinf/code-validator/pkg/types"
+)
+
+// The special judge protocol is modelled on testlib. The judge is run as
+//
+//	./.judge ./input ./output ./answer
+//
+// and reports the verdict by its exit code. A partial score is written to ./score
+// as a fraction of the case, and the feedback to ./message or the standard error.
+const (
+	JudgeExitAccepted    = 0
+	JudgeExitWrongAnswer = 1
+	// JudgeExitPresentation is a wrong answer caused by the format of the output
+	JudgeExitPresentation = 2
+	// JudgeExitFail means the judge itself failed, such as an answer that is not valid
+	JudgeExitFail    = 3
+	JudgeExitPartial = 7
+
+	JudgeScoreFile   = "score"
+	JudgeMessageFile = "message"
+
+	judgeStepName = "judge"
+	judgeBoxPath  = "./.judge"
+	// judgeBinary is the output of the compile step of a runtime that runs ./main
+	judgeBinary      = "main"
+	judgeContentType = "application/octet-stream"
+	defaultJudgeLang = types.CPPRuntime
+)
+
+// SpecialJudge is a checker program written by the author of the verification
+type SpecialJudge struct {
+	// Runtime of the judge, the default is C++, it must build a single binary
+	Runtime string         `json:"runtime,omitempty"`
+	Version string         `json:"version,omitempty"`
+	Compile *CompileOption `json:"compile,omitempty"`
+	Source  File           `json:"source"`
+}
+
+func (j *SpecialJudge) getRuntime() types.Runtime {
+	rt := types.Runtime{
+		Lang:    j.Runtime,
+		Version: j.Version,
+	}
+	if rt.Lang == "" {
+		rt.Lang = defaultJudgeLang
+	}
+
+	return rt
+}
+
+func (j *SpecialJudge) validate() error {
+	if j.Source.OssPath == "" {
+		return fmt.Errorf("the source of the special judge cannot be empty")
+	}
+	spec, ok := Lookup(j.getRuntime())
+	if !ok {
+		return fmt.Errorf("runtime %s of the special judge is not registered", j.getRuntime())
+	}
+	if spec.Compile == nil || spec.Run.Cmd != "./"+judgeBinary {
+		return fmt.Errorf("runtime %s cannot build the special judge into a binary", j.getRuntime())
+	}
+
+	return nil
+}
+
+// command is the verify step of a case
+func (j *SpecialJudge) command() string {
+	// the files of the judge are removed first, since the program of the case runs in the same box
+	return fmt.Sprintf("rm -f ./%s ./%s && chmod +x %[3]s && %[3]s ./input ./output ./answer",
+		JudgeScoreFile, JudgeMessageFile, judgeBoxPath)
+}
+
+// buildJudge returns the binary of the judge, it is compiled once and cached in the directory of the batch
+func buildJudge(id int, j *SpecialJudge, srcDir, stepOutDir string) (binary []byte, message string, err error) {
+	if err = j.validate(); err != nil {
+		return nil, "", err
+	}
+	spec, _ := Lookup(j.getRuntime())
+	// the judge is built by the actuator of the verification, whose image may not have the runtime of the judge
+	if !spec.Available() {
+		return nil, fmt.Sprintf("runtime %s of the special judge is not installed on the actuator", spec.Runtime), nil
+	}
+	source, err := ReadOSSFile(path.Join(srcDir, j.Source.OssPath))
+	if err != nil {
+		return nil, "", err
+	}
+
+	cachePath := path.Join(srcDir, judgeStepName, judgeKey(j, source))
+	if binary, err = storage.Get(context.Background(), cachePath); err == nil {
+		return binary, "", nil
+	}
+
+	templates, err := spec.Templates(j.Compile, "")
+	if err != nil {
+		return nil, "", err
+	}
+	pl := &pipeline.Pipeline{
+		Steps: []pipeline.Step{
+			{
+				Name:     CompileStepName,
+				Template: CompileStepName,
+				FileRefs: []pipeline.FileRef{
+					{
+						DataRef: pipeline.DataRef{
+							ExternalRef: &pipeline.ExternalRef{FileName: judgeStepName},
+						},
+						Path: spec.Source,
+					},
+				},
+			},
+		},
+		Templates: templates,
+		Files: []pipeline.File{
+			{
+				Name:    judgeStepName,
+				Content: source,
+			},
+		},
+	}
+	res, out, err := execute(id, pl, path.Join(stepOutDir, judgeStepName), judgeBinary)
+	if err != nil {
+		return nil, "", err
+	}
+	if e, ok := res.Errs[CompileStepName]; ok {
+		return nil, "special judge " + compileMessage(res.Outs[CompileStepName], e), nil
+	}
+	binary, ok := out[judgeBinary]
+	if !ok {
+		return nil, "", fmt.Errorf("the compile step of runtime %s does not build ./%s", spec.Runtime, judgeBinary)
+	}
+	if err = storage.Put(context.Background(), cachePath, bytes.NewReader(binary), int64(len(binary)), judgeContentType); err != nil {
+		return nil, "", err
+	}
+
+	return binary, "", nil
+}
+
+func judgeKey(j *SpecialJudge, source []byte) string {
+	h := sha256.New()
+	h.Write([]byte(j.getRuntime().String()))
+	if j.Compile != nil {
+		h.Write([]byte(j.Compile.Std + "\x00" + j.Compile.Optimize))
+	}
+	h.Write([]byte{0})
+	h.Write(source)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// judgeVerdict reads the verdict of the special judge, partial is the fraction of the case that is earned
+func judgeVerdict(res *pipeline.Result, out map[string][]byte) (verdict Verdict, partial float64, feedback string) {
+	feedback = strings.TrimSpace(string(out[JudgeMessageFile]))
+	if feedback == "" {
+		feedback = strings.TrimSpace(string(res.Outs[VerifyStepName]))
+	}
+	if len(feedback) > maxDiagnosticsLen {
+		feedback = feedback[:maxDiagnosticsLen] + "..."
+	}
+
+	meta, ok := res.Metas[VerifyStepName]
+	if !ok || (meta.Status != "" && meta.Status != "RE") {
+		// the judge was killed or timed out
+		return VerdictInternalError, 0, feedback
+	}
+	switch meta.ExitCode {
+	case JudgeExitAccepted:
+		return VerdictAccepted, 1, feedback
+	case JudgeExitWrongAnswer, JudgeExitPresentation:
+		return VerdictWrongAnswer, 0, feedback
+	case JudgeExitPartial:
+		partial, err := strconv.ParseFloat(strings.TrimSpace(string(out[JudgeScoreFile])), 64)
+		if err != nil || math.IsNaN(partial) || math.IsInf(partial, 0) || partial < 0 || partial > 1 {
+			return VerdictInternalError, 0, fmt.Sprintf("the special judge wrote an invalid score %q", out[JudgeScoreFile])
+		}
+		switch partial {
+		case 0:
+			return VerdictWrongAnswer, 0, feedback
+		case 1:
+			return VerdictAccepted, 1, feedback
+		}
+
+		return VerdictPartial, partial, feedback
+	default:
+		return VerdictInternalError, 0, feedback
+	}
+}
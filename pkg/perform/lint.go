@@ -0,0 +1,295 @@
+package perform
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/vincent-vinf/code-validator/pkg/pipeline"
+	"github.com/vincent-vinf/code-validator/pkg/util/lint"
+)
+
+const (
+	AnalyzerPylint = "pylint"
+	AnalyzerFlake8 = "flake8"
+	AnalyzerESLint = "eslint"
+	AnalyzerGoVet  = "go-vet"
+
+	LintStepName = "lint"
+
+	defaultLintReport = "./lint-report"
+	// boxDir is the working directory inside the sandbox, it is removed from the absolute paths of the findings
+	boxDir = "/box/"
+	// maxFindings bounds the findings kept in a report
+	maxFindings = 200
+)
+
+type analyzer struct {
+	template pipeline.Template
+	format   string
+}
+
+// analyzers are the commands that write a machine-readable report to ./lint-report,
+// pylint and flake8 are part of the python image, eslint comes from the dependencies of the project
+var analyzers = map[string]analyzer{
+	AnalyzerPylint: {
+		template: pipeline.Template{
+			Cmd: "/bin/sh",
+			Args: []string{"-c", "/usr/local/bin/python -m pylint --output-format=json --exit-zero " +
+				"$(find . -name '*.py' -not -path './.*') > " + defaultLintReport},
+		},
+		format: lint.FormatPylint,
+	},
+	AnalyzerFlake8: {
+		template: pipeline.Template{
+			Cmd:  "/bin/sh",
+			Args: []string{"-c", "/usr/local/bin/python -m flake8 --exit-zero . > " + defaultLintReport},
+		},
+		format: lint.FormatFlake8,
+	},
+	AnalyzerESLint: {
+		template: pipeline.Template{
+			Cmd:  "/bin/sh",
+			Args: []string{"-c", "npx --no-install eslint -f json . > " + defaultLintReport},
+		},
+		format: lint.FormatESLint,
+	},
+	AnalyzerGoVet: {
+		template: pipeline.Template{
+			Cmd: "/bin/sh",
+			// a submission of a single file has no go.mod
+			Args: []string{"-c", "if [ -f go.mod ]; then pkgs=./...; else pkgs=*.go; fi; " +
+				"/usr/local/go/bin/go vet -json $pkgs 2> " + defaultLintReport},
+		},
+		format: lint.FormatGoVet,
+	},
+}
+
+// LintVerification runs a static analyzer against the code, and scores the code by its findings
+type LintVerification struct {
+	// Analyzer selects the command and the format of a known analyzer
+	Analyzer string `json:"analyzer,omitempty"`
+	// Command is a shell command that writes the report, it takes precedence over Analyzer
+	Command string `json:"command,omitempty"`
+	// Format of the report written by Command, such as pylint-json, flake8, eslint-json or go-vet-json
+	Format string `json:"format,omitempty"`
+	// Report is the path of the report written by Command, the default is ./lint-report
+	Report string `json:"report,omitempty"`
+	// Files are the configuration of the analyzer, such as .pylintrc, which are written to the box after the code
+	Files []File `json:"files"`
+	Limit *Limit `json:"limit,omitempty"`
+	// Points is the score of the code without findings, the default is 10
+	Points float64 `json:"points,omitempty"`
+	// Penalties are the points deducted for every finding by severity,
+	// the default is 1 for an error, 0.5 for a warning and 0.1 for an info
+	Penalties map[lint.Severity]float64 `json:"penalties,omitempty"`
+	// Thresholds are the maximum numbers of findings by severity that pass, a missing severity is not limited.
+	// The default only passes the code without errors.
+	Thresholds map[lint.Severity]int `json:"thresholds,omitempty"`
+}
+
+var (
+	defaultLintPenalties  = map[lint.Severity]float64{lint.Error: 1, lint.Warning: 0.5, lint.Info: 0.1}
+	defaultLintThresholds = map[lint.Severity]int{lint.Error: 0}
+)
+
+func (l *LintVerification) Validate() error {
+	if l.Command == "" {
+		if _, ok := analyzers[l.Analyzer]; !ok {
+			return fmt.Errorf("unsupported analyzer %s, a command is required", l.Analyzer)
+		}
+	} else if !lint.Supported(l.format()) {
+		return fmt.Errorf("unsupported report format %s", l.Format)
+	}
+	if l.Points < 0 {
+		return fmt.Errorf("the points cannot be negative")
+	}
+	for s, p := range l.Penalties {
+		if !validSeverity(s) || p < 0 {
+			return fmt.Errorf("invalid penalty %v of severity %s", p, s)
+		}
+	}
+	for s, n := range l.Thresholds {
+		if !validSeverity(s) || n < 0 {
+			return fmt.Errorf("invalid threshold %d of severity %s", n, s)
+		}
+	}
+
+	return nil
+}
+
+func validSeverity(s lint.Severity) bool {
+	return s == lint.Error || s == lint.Warning || s == lint.Info
+}
+
+func (l *LintVerification) template() pipeline.Template {
+	t := analyzers[l.Analyzer].template
+	if l.Command != "" {
+		t = pipeline.Template{
+			Cmd:  "/bin/sh",
+			Args: []string{"-c", l.Command},
+		}
+	}
+	t.Name = RunStepName
+
+	return t
+}
+
+func (l *LintVerification) format() string {
+	if l.Command != "" {
+		return l.Format
+	}
+
+	return analyzers[l.Analyzer].format
+}
+
+func (l *LintVerification) report() string {
+	if l.Command != "" && l.Report != "" {
+		return l.Report
+	}
+
+	return defaultLintReport
+}
+
+// MaxScore is the score of the code without findings
+func (l *LintVerification) MaxScore() float64 {
+	if l.Points > 0 {
+		return l.Points
+	}
+
+	return 10
+}
+
+// score deducts the penalties of the findings from the points, and checks the numbers of the findings
+// against the thresholds, the message lists the numbers, such as "2 errors, 1 warning"
+func (l *LintVerification) score(findings []lint.Finding) (score float64, pass bool, message string) {
+	penalties, thresholds := l.Penalties, l.Thresholds
+	if penalties == nil {
+		penalties = defaultLintPenalties
+	}
+	if thresholds == nil {
+		thresholds = defaultLintThresholds
+	}
+
+	score = l.MaxScore()
+	for _, f := range findings {
+		score -= penalties[f.Severity]
+	}
+	score = math.Max(0, math.Round(score*100)/100)
+
+	pass = true
+	counts := lint.Count(findings)
+	var parts, exceeded []string
+	for _, s := range []lint.Severity{lint.Error, lint.Warning, lint.Info} {
+		n := counts[s]
+		if n > 0 {
+			parts = append(parts, plural(n, string(s)))
+		}
+		if limit, ok := thresholds[s]; ok && n > limit {
+			pass = false
+			exceeded = append(exceeded, fmt.Sprintf("more than %s", plural(limit, string(s))))
+		}
+	}
+	message = "no findings"
+	if len(parts) > 0 {
+		message = strings.Join(parts, ", ")
+	}
+	if len(exceeded) > 0 {
+		message += ", " + strings.Join(exceeded, " and ")
+	}
+
+	return score, pass, message
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, word)
+	}
+
+	return fmt.Sprintf("%d %ss", n, word)
+}
+
+func runLint(spec *RuntimeSpec, l *LintVerification, sub Submission, srcDir, stepOutDir string) (*Report, error) {
+	rep := &Report{
+		MaxScore: l.MaxScore(),
+	}
+
+	tool := &toolRun{
+		stepName: LintStepName,
+		template: l.template(),
+		files:    l.Files,
+		limit:    l.Limit,
+		report:   l.report(),
+	}
+	out, err := runTool(spec, tool, rep, sub, srcDir, stepOutDir)
+	if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		return rep, nil
+	}
+	if !out.reported {
+		rep.Verdict = VerdictInternalError
+		rep.Message = lintMessage("the analyzer did not write a report", out.res.Outs[RunStepName])
+
+		return rep, nil
+	}
+	findings, err := lint.Parse(l.format(), out.report)
+	var compileErr *lint.CompileError
+	switch {
+	case errors.As(err, &compileErr):
+		rep.Verdict = VerdictCompilationError
+		rep.Message = compileMessage([]byte(compileErr.Output), err)
+
+		return rep, nil
+	case err != nil:
+		rep.Verdict = VerdictInternalError
+		rep.Message = lintMessage(fmt.Sprintf("failed to parse the report %s: %s", l.report(), err), out.res.Outs[RunStepName])
+
+		return rep, nil
+	}
+
+	rep.Score, rep.Pass, rep.Message = l.score(findings)
+	rep.Verdict = VerdictAccepted
+	if !rep.Pass {
+		rep.Verdict = VerdictWrongAnswer
+	}
+	rep.Findings = trimFindings(findings)
+	if len(findings) > len(rep.Findings) {
+		rep.Message += fmt.Sprintf(", only the first %d findings are kept", len(rep.Findings))
+	}
+
+	return rep, nil
+}
+
+// trimFindings makes the paths relative to the box, and keeps the most severe findings if there are too many
+func trimFindings(findings []lint.Finding) []lint.Finding {
+	for i := range findings {
+		findings[i].File = strings.TrimPrefix(strings.TrimPrefix(findings[i].File, boxDir), "./")
+		if len(findings[i].Message) > maxDiagnosticsLen {
+			findings[i].Message = findings[i].Message[:maxDiagnosticsLen] + "..."
+		}
+	}
+	if len(findings) <= maxFindings {
+		return findings
+	}
+	rank := map[lint.Severity]int{lint.Error: 0, lint.Warning: 1, lint.Info: 2}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return rank[findings[i].Severity] < rank[findings[j].Severity]
+	})
+
+	return findings[:maxFindings]
+}
+
+func lintMessage(msg string, out []byte) string {
+	if len(out) > 0 {
+		msg += ":\n" + strings.TrimSpace(string(out))
+	}
+	if len(msg) > maxDiagnosticsLen {
+		msg = msg[:maxDiagnosticsLen] + "..."
+	}
+
+	return msg
+}
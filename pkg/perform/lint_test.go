@@ -0,0 +1,42 @@
+package perform
+
+import (
+	"testing"
+
+	"github.com/vincent-vinf/code-validator/pkg/util/lint"
+)
+
+func TestLintScore(t *testing.T) {
+	findings := []lint.Finding{
+		{Severity: lint.Error},
+		{Severity: lint.Warning},
+		{Severity: lint.Warning},
+		{Severity: lint.Info},
+	}
+	tests := []struct {
+		name    string
+		lint    LintVerification
+		score   float64
+		pass    bool
+		message string
+	}{
+		{"default", LintVerification{}, 7.9, false, "1 error, 2 warnings, 1 info, more than 0 errors"},
+		{"thresholds", LintVerification{
+			Points:     5,
+			Penalties:  map[lint.Severity]float64{lint.Error: 2},
+			Thresholds: map[lint.Severity]int{lint.Error: 1, lint.Warning: 2},
+		}, 3, true, "1 error, 2 warnings, 1 info"},
+		{"floor", LintVerification{Points: 1}, 0, false, "1 error, 2 warnings, 1 info, more than 0 errors"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, pass, message := tt.lint.score(findings)
+			if score != tt.score || pass != tt.pass || message != tt.message {
+				t.Errorf("score() = %v, %v, %q, want %v, %v, %q", score, pass, message, tt.score, tt.pass, tt.message)
+			}
+		})
+	}
+	if _, pass, message := (&LintVerification{}).score(nil); !pass || message != "no findings" {
+		t.Errorf("score(nil) = %v, %q", pass, message)
+	}
+}
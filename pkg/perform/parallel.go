@@ -0,0 +1,179 @@
+package perform
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// maxParallelism bounds the boxes used by one verification
+const maxParallelism = 16
+
+// cpuPool holds the free cpus that the cases are pinned to, it is nil when the cases are not pinned
+var cpuPool chan int
+
+// SetPinnedCPUs pins every case to one of the cpus, so that the cases running at the same time,
+// even of different verifications, do not share a cpu and their cpu time stays comparable
+func SetPinnedCPUs(cpus []int) {
+	if len(cpus) == 0 {
+		cpuPool = nil
+		return
+	}
+	pool := make(chan int, len(cpus))
+	for _, c := range cpus {
+		pool <- c
+	}
+	cpuPool = pool
+}
+
+// acquireCPU blocks until a cpu is free, pinned is false when the cases are not pinned
+func acquireCPU() (cpu int, pinned bool) {
+	if cpuPool == nil {
+		return 0, false
+	}
+
+	return <-cpuPool, true
+}
+
+func releaseCPU(cpu int) {
+	cpuPool <- cpu
+}
+
+// workers returns the number of boxes that run the cases at the same time
+func (c *CodeVerification) workers() int {
+	n := c.Parallelism
+	if n > maxParallelism {
+		n = maxParallelism
+	}
+	if cpuPool != nil && n > cap(cpuPool) {
+		n = cap(cpuPool)
+	}
+	if n < 1 {
+		n = 1
+	}
+
+	return n
+}
+
+type caseOutcome struct {
+	result CaseResult
+	// compileMessage is set when the code cannot be compiled
+	compileMessage string
+	err            error
+}
+
+// evaluate runs the cases according to the policy of the verification, the outcomes keep the order of the cases
+func (c *CodeVerification) evaluate(id int, run func(id int, tc TestCase) caseOutcome) []caseOutcome {
+	if c.Policy != PolicySampleFirst {
+		return runCases(id, c.workers(), c.Cases, c.Policy == PolicyFailFast, run)
+	}
+
+	var samples, hidden []int
+	for i := range c.Cases {
+		if c.Cases[i].visibility() == VisibilitySample {
+			samples = append(samples, i)
+		} else {
+			hidden = append(hidden, i)
+		}
+	}
+	outcomes := make([]caseOutcome, len(c.Cases))
+	scatter := func(indexes []int, res []caseOutcome) {
+		for i, o := range res {
+			outcomes[indexes[i]] = o
+		}
+	}
+	res := runCases(id, c.workers(), pickCases(c.Cases, samples), false, run)
+	scatter(samples, res)
+	if failed(res) {
+		for _, i := range hidden {
+			outcomes[i] = skippedCase(c.Cases[i])
+		}
+
+		return outcomes
+	}
+	scatter(hidden, runCases(id, c.workers(), pickCases(c.Cases, hidden), false, run))
+
+	return outcomes
+}
+
+func pickCases(cases []TestCase, indexes []int) []TestCase {
+	res := make([]TestCase, 0, len(indexes))
+	for _, i := range indexes {
+		res = append(res, cases[i])
+	}
+
+	return res
+}
+
+func failed(outcomes []caseOutcome) bool {
+	for _, o := range outcomes {
+		if o.stops(true) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func skippedCase(tc TestCase) caseOutcome {
+	return caseOutcome{
+		result: CaseResult{
+			Name:       tc.Name,
+			Verdict:    VerdictSkipped,
+			Message:    "skipped after a failed case",
+			Visibility: tc.visibility(),
+		},
+	}
+}
+
+// stops reports whether the remaining cases are skipped after the outcome
+func (o *caseOutcome) stops(failFast bool) bool {
+	return o.err != nil || o.compileMessage != "" || (failFast && !o.result.Pass)
+}
+
+// runCases runs the cases in up to workers boxes, id is the box of the verification and more are acquired if needed.
+// The outcomes keep the order of the cases, and the cases that have not started are skipped
+// after a compile or internal error, or after any failed case if failFast is set
+func runCases(id, workers int, cases []TestCase, failFast bool, run func(id int, tc TestCase) caseOutcome) []caseOutcome {
+	if workers > len(cases) {
+		workers = len(cases)
+	}
+	ids := []int{id}
+	for len(ids) < workers {
+		extra, err := idDispatcher.Get()
+		if err != nil {
+			// the cases still run in the boxes that are acquired
+			break
+		}
+		defer idDispatcher.Release(extra)
+		ids = append(ids, extra)
+	}
+
+	next := make(chan int, len(cases))
+	outcomes := make([]caseOutcome, len(cases))
+	for i := range cases {
+		next <- i
+		outcomes[i] = skippedCase(cases[i])
+	}
+	close(next)
+
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	for _, boxID := range ids {
+		wg.Add(1)
+		go func(boxID int) {
+			defer wg.Done()
+			for i := range next {
+				if stop.Load() {
+					return
+				}
+				outcomes[i] = run(boxID, cases[i])
+				if outcomes[i].stops(failFast) {
+					stop.Store(true)
+				}
+			}
+		}(boxID)
+	}
+	wg.Wait()
+
+	return outcomes
+}
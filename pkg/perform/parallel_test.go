@@ -0,0 +1,82 @@
+package perform
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunCases(t *testing.T) {
+	var cases []TestCase
+	for i := 0; i < 20; i++ {
+		cases = append(cases, TestCase{Name: fmt.Sprint(i)})
+	}
+	var running, peak int32
+	outcomes := runCases(0, 4, cases, false, func(id int, tc TestCase) caseOutcome {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&running, -1)
+
+		return caseOutcome{result: CaseResult{Name: tc.Name}}
+	})
+	for i, o := range outcomes {
+		if o.result.Name != cases[i].Name {
+			t.Fatalf("outcome %d is of case %s", i, o.result.Name)
+		}
+	}
+	if peak < 2 || peak > 4 {
+		t.Errorf("%d cases ran at the same time, want 2 to 4", peak)
+	}
+
+	var ran int32
+	outcomes = runCases(0, 1, cases, false, func(id int, tc TestCase) caseOutcome {
+		atomic.AddInt32(&ran, 1)
+		return caseOutcome{compileMessage: "compile error"}
+	})
+	if ran != 1 || outcomes[0].compileMessage == "" || outcomes[1].result.Verdict != VerdictSkipped {
+		t.Errorf("ran %d cases after a compile error, want 1", ran)
+	}
+}
+
+func TestEvaluatePolicy(t *testing.T) {
+	code := &CodeVerification{
+		Cases: []TestCase{{Name: "1"}, {Name: "sample", Visibility: VisibilitySample}, {Name: "3"}, {Name: "4"}},
+	}
+	// every case fails except the sample
+	run := func(id int, tc TestCase) caseOutcome {
+		pass := tc.Name == "sample"
+		return caseOutcome{result: CaseResult{Name: tc.Name, Pass: pass}}
+	}
+	verdicts := func(outcomes []caseOutcome) (res []Verdict) {
+		for _, o := range outcomes {
+			res = append(res, o.result.Verdict)
+		}
+		return res
+	}
+
+	code.Policy = PolicyFailFast
+	got := verdicts(code.evaluate(0, run))
+	if got[0] == VerdictSkipped || got[1] != VerdictSkipped || got[3] != VerdictSkipped {
+		t.Errorf("fail-fast verdicts = %v", got)
+	}
+
+	code.Policy = PolicySampleFirst
+	got = verdicts(code.evaluate(0, run))
+	for i, v := range got {
+		if v == VerdictSkipped {
+			t.Errorf("sample-first skipped case %d after the sample passed", i)
+		}
+	}
+	code.Cases[1].Name = "broken sample"
+	got = verdicts(code.evaluate(0, run))
+	if got[1] == VerdictSkipped || got[0] != VerdictSkipped || got[2] != VerdictSkipped || got[3] != VerdictSkipped {
+		t.Errorf("sample-first verdicts = %v, want the hidden cases skipped", got)
+	}
+}
@@ -6,22 +6,23 @@ import (
 	"fmt"
 	"os"
 	"path"
-	"strings"
 
 	"github.com/vincent-vinf/code-validator/pkg/pipeline"
+	"github.com/vincent-vinf/code-validator/pkg/sandbox"
 	"github.com/vincent-vinf/code-validator/pkg/util/dispatcher"
 	"github.com/vincent-vinf/code-validator/pkg/util/oss"
 )
 
 const (
-	InitStepName   = "init"
-	RunStepName    = "run"
-	VerifyStepName = "verify"
+	InitStepName    = "init"
+	CompileStepName = "compile"
+	RunStepName     = "run"
+	VerifyStepName  = "verify"
 )
 
 var (
 	idDispatcher *dispatcher.Dispatcher
-	ossClient    *oss.Client
+	storage      oss.Storage
 )
 
 func init() {
@@ -32,21 +33,59 @@ func init() {
 	}
 }
 
-func Perform(vf *Verification, codeOssPath string, srcDir, stepOutDir string) (*Report, error) {
-	if err := validate(vf); err != nil {
+func Perform(vf *Verification, sub Submission, srcDir, stepOutDir string) (*Report, error) {
+	spec, err := validate(vf)
+	if err != nil {
 		return nil, err
 	}
 	switch {
 	case vf.Code != nil:
-		return runCode(vf.Code, codeOssPath, srcDir, stepOutDir)
+		return runCode(spec, vf.Code, sub, srcDir, stepOutDir)
 	case vf.Custom != nil:
-		return runCustom(vf.Custom, codeOssPath, srcDir, stepOutDir)
+		return runCustom(vf.Custom, sub, srcDir, stepOutDir)
+	case vf.Suite != nil:
+		return runSuite(spec, vf.Suite, sub, srcDir, stepOutDir)
+	case vf.Lint != nil:
+		return runLint(spec, vf.Lint, sub, srcDir, stepOutDir)
 	default:
 		return nil, errors.New("verification name cannot be empty")
 	}
 }
 
-func runCode(code *CodeVerification, codePath string, srcDir, stepOutDir string) (*Report, error) {
+func runCode(spec *RuntimeSpec, code *CodeVerification, sub Submission, srcDir, stepOutDir string) (*Report, error) {
+	rep := &Report{
+		Pass:     true,
+		Cases:    nil,
+		MaxScore: code.MaxScore(),
+	}
+	if code.Generator != nil && len(code.Cases) == 0 {
+		rep.Pass = false
+		rep.Verdict = VerdictInternalError
+		rep.Message = "the cases of the verification have not been generated"
+
+		return rep, nil
+	}
+
+	codeFiles, codeRefs, err := loadSubmission(sub, spec.Source)
+	if err != nil {
+		rep.Pass = false
+		rep.Verdict = VerdictInternalError
+		rep.Message = fmt.Sprintf("failed to get code file, path: %s, err: %s", sub.OssPath, err)
+
+		return rep, nil
+	}
+	var entry string
+	if sub.IsArchive() {
+		entry, err = entryPoint(spec, code.Entry, codeRefs)
+		if err != nil {
+			rep.Pass = false
+			rep.Verdict = VerdictCompilationError
+			rep.Message = err.Error()
+
+			return rep, nil
+		}
+	}
+
 	var steps []pipeline.Step
 	var files []pipeline.File
 	if code.Init != nil {
@@ -59,8 +98,30 @@ func runCode(code *CodeVerification, codePath string, srcDir, stepOutDir string)
 		files = append(files, fs...)
 	}
 
-	templates := GetCodeTemplates()
-	steps = append(steps, GetCodeSteps()...)
+	templates, err := spec.Templates(code.Compile, entry)
+	if err != nil {
+		return nil, err
+	}
+	var mounts []sandbox.Dir
+	if lockfile := findLockfile(spec, codeFiles, codeRefs); lockfile != nil {
+		envDir, out, err := installDependencies(spec, lockfile)
+		if err != nil {
+			rep.Pass = false
+			rep.Verdict = VerdictDependencyError
+			rep.Message = dependencyMessage(out, err)
+
+			return rep, nil
+		}
+		mounts = append(mounts, depsMount(envDir))
+		withDependencyEnv(spec, templates)
+	}
+	initSteps := steps
+	if spec.prebuilt() {
+		steps = append(append([]pipeline.Step(nil), initSteps...), prebuiltSteps(codeRefs)...)
+	} else {
+		steps = append(steps, spec.Steps(codeRefs)...)
+	}
+	files = append(files, codeFiles...)
 	// get verify files
 	fs, err := ToPipelineFile(srcDir, VerifyStepName, code.Files)
 	if err != nil {
@@ -78,6 +139,26 @@ func runCode(code *CodeVerification, codePath string, srcDir, stepOutDir string)
 			Path: code.Files[i].Path,
 		})
 	}
+	var outFiles []string
+	if code.useJudge() {
+		verifyFileRefs = append(verifyFileRefs,
+			pipeline.FileRef{
+				DataRef: pipeline.DataRef{
+					ExternalRef: &pipeline.ExternalRef{FileName: "input"},
+				},
+				Path:       "./input",
+				AutoRemove: true,
+			},
+			pipeline.FileRef{
+				DataRef: pipeline.DataRef{
+					ExternalRef: &pipeline.ExternalRef{FileName: judgeStepName},
+				},
+				Path:       judgeBoxPath,
+				AutoRemove: true,
+			},
+		)
+		outFiles = []string{JudgeScoreFile, JudgeMessageFile}
+	}
 	steps = append(steps, pipeline.Step{
 		Name: VerifyStepName,
 		InlineTemplate: &pipeline.Template{
@@ -85,10 +166,13 @@ func runCode(code *CodeVerification, codePath string, srcDir, stepOutDir string)
 			Cmd:  "/bin/sh",
 			Args: []string{
 				"-c",
-				code.Verify,
+				code.verifyCommand(),
 			},
 		},
 		ContinueOnFail: true,
+		LogMate:        code.useJudge(),
+		// the verify command and the special judge run without the network like the program of the case
+		Limit: &pipeline.Limit{EnableNetWork: false},
 		FileRefs: append([]pipeline.FileRef{
 			{
 				DataRef: pipeline.DataRef{
@@ -108,18 +192,6 @@ func runCode(code *CodeVerification, codePath string, srcDir, stepOutDir string)
 	},
 	)
 
-	rep := &Report{
-		Pass:  true,
-		Cases: nil,
-	}
-
-	codeData, err := ReadOSSFile(codePath)
-	if err != nil {
-		rep.Pass = false
-		rep.Message = fmt.Sprintf("failed to get code file, path: %s, err: %s", codePath, err)
-
-		return rep, nil
-	}
 	id, err := idDispatcher.Get()
 	if err != nil {
 		// Too many verification items are running at the same time,
@@ -128,78 +200,188 @@ func runCode(code *CodeVerification, codePath string, srcDir, stepOutDir string)
 	}
 	defer idDispatcher.Release(id)
 
-	for _, tc := range code.Cases {
-		cr := CaseResult{
-			Name: tc.Name,
-			Pass: false,
+	if code.useJudge() {
+		binary, msg, err := buildJudge(id, code.Judge, srcDir, stepOutDir)
+		if err != nil {
+			return nil, err
 		}
+		if msg != "" {
+			rep.Pass = false
+			rep.Verdict = VerdictInternalError
+			rep.Message = msg
 
-		inData, err := ReadOSSFile(path.Join(srcDir, tc.In.OssPath))
-		if err != nil {
-			// Failed to read file, skip test case
-			cr.Message = err.Error()
-			rep.Cases = append(rep.Cases, cr)
-			continue
+			return rep, nil
 		}
-		outData, err := ReadOSSFile(path.Join(srcDir, tc.Out.OssPath))
+		files = append(files, pipeline.File{
+			Name:    judgeStepName,
+			Content: binary,
+		})
+	}
+
+	if spec.prebuilt() {
+		base := pipeline.Pipeline{Steps: initSteps, Templates: templates, Files: files, Mounts: mounts}
+		artifacts, msg, err := build(id, spec, base, codeRefs, path.Join(stepOutDir, buildDir))
 		if err != nil {
-			cr.Message = err.Error()
-			rep.Cases = append(rep.Cases, cr)
-			continue
+			return nil, err
 		}
-		pl := &pipeline.Pipeline{
-			Steps:     steps,
-			Templates: templates,
-			Files: append(files,
-				pipeline.File{
-					Name:    "code",
-					Content: codeData,
-				},
-				pipeline.File{
-					Name:    "output",
-					Content: outData,
-				},
-				pipeline.File{
-					Name:    "input",
-					Content: inData,
-				},
-			),
+		if msg != "" {
+			rep.Pass = false
+			rep.Verdict = VerdictCompilationError
+			rep.Message = msg
+
+			return rep, nil
 		}
-		res, _, _, err := execute(id, pl, path.Join(stepOutDir, tc.Name))
-		if err != nil {
-			return nil, err
+		files = append(files, pipeline.File{
+			Name:    artifactsFile,
+			Content: artifacts,
+		})
+	}
+
+	r := &caseRunner{
+		spec:       spec,
+		code:       code,
+		steps:      steps,
+		templates:  templates,
+		mounts:     mounts,
+		files:      files,
+		outFiles:   outFiles,
+		srcDir:     srcDir,
+		stepOutDir: stepOutDir,
+	}
+	outcomes := code.evaluate(id, r.run)
+	for _, o := range outcomes {
+		if o.err != nil {
+			return nil, o.err
 		}
-		if _, ok := res.Errs[VerifyStepName]; ok {
-			cr.Pass = false
+		if o.compileMessage != "" {
+			// the code is the same for every case, so the remaining cases would fail in the same way
 			rep.Pass = false
-		} else {
-			cr.Pass = true
+			rep.Verdict = VerdictCompilationError
+			rep.Cases = nil
+			rep.Message = o.compileMessage
+
+			return rep, nil
 		}
-		meta, ok := res.Metas[RunStepName]
-		if !ok {
-			cr.Message = fmt.Sprintf("the metadata of test case %s is missing", tc.Name)
-		} else {
-			cr.ExitCode = meta.ExitCode
-			cr.Time = meta.Time
-			cr.Memory = meta.MaxRSS
+	}
+	for _, o := range outcomes {
+		if !o.result.Pass {
+			rep.Pass = false
 		}
-		rep.Cases = append(rep.Cases, cr)
+		rep.Cases = append(rep.Cases, o.result)
 	}
+	rep.Verdict = aggregateVerdict(rep.Cases)
+	if rep.Verdict != VerdictAccepted {
+		rep.Pass = false
+	}
+	rep.Score, rep.Groups = code.Score(rep.Cases)
 
 	return rep, nil
 }
 
-func runCustom(custom *CustomVerification, codePath string, srcDir, stepOutDir string) (*Report, error) {
-	rep := &Report{
-		Pass: true,
+// caseRunner runs the cases of a code verification, the steps and files are shared by the cases
+type caseRunner struct {
+	spec       *RuntimeSpec
+	code       *CodeVerification
+	steps      []pipeline.Step
+	templates  []pipeline.Template
+	mounts     []sandbox.Dir
+	files      []pipeline.File
+	outFiles   []string
+	srcDir     string
+	stepOutDir string
+}
+
+func (r *caseRunner) run(id int, tc TestCase) caseOutcome {
+	limit := r.code.Limit.merge(tc.Limit)
+	runLimit := r.spec.runLimit(limit)
+	cr := CaseResult{
+		Name:          tc.Name,
+		Pass:          false,
+		Verdict:       VerdictInternalError,
+		Visibility:    tc.visibility(),
+		TimeLimit:     runLimit.Time.Seconds(),
+		WallTimeLimit: runLimit.WallTime.Seconds(),
+		MemoryLimit:   runLimit.Memory,
 	}
 
-	codeData, err := ReadOSSFile(codePath)
+	inData, err := ReadOSSFile(path.Join(r.srcDir, tc.In.OssPath))
 	if err != nil {
-		rep.Pass = false
-		rep.Message = fmt.Sprintf("failed to get code file, path:%s, err: %s", codePath, err)
+		// Failed to read file, skip test case
+		cr.Message = err.Error()
+		return caseOutcome{result: cr}
+	}
+	outData, err := ReadOSSFile(path.Join(r.srcDir, tc.Out.OssPath))
+	if err != nil {
+		cr.Message = err.Error()
+		return caseOutcome{result: cr}
+	}
+	cpu, pinned := acquireCPU()
+	if pinned {
+		defer releaseCPU(cpu)
+	}
+	pl := &pipeline.Pipeline{
+		Steps:     withRunLimit(r.steps, runLimit),
+		Templates: r.templates,
+		Mounts:    r.mounts,
+		// the files are copied since the cases may run at the same time
+		Files: append(append([]pipeline.File(nil), r.files...),
+			pipeline.File{
+				Name:    "output",
+				Content: outData,
+			},
+			pipeline.File{
+				Name:    "input",
+				Content: inData,
+			},
+		),
+	}
+	if pinned {
+		pl.CPUs = []int{cpu}
+	}
+	res, out, err := execute(id, pl, path.Join(r.stepOutDir, tc.Name), r.outFiles...)
+	if err != nil {
+		return caseOutcome{err: err}
+	}
+	if e, ok := res.Errs[CompileStepName]; ok {
+		return caseOutcome{compileMessage: compileMessage(res.Outs[CompileStepName], e)}
+	}
+	cr.Verdict = caseVerdict(res)
+	if r.code.useJudge() && (cr.Verdict == VerdictAccepted || cr.Verdict == VerdictWrongAnswer) {
+		cr.Verdict, cr.Partial, cr.Message = judgeVerdict(res, out)
+	}
+	cr.Pass = cr.Verdict == VerdictAccepted
+	if !cr.Pass {
+		if e, ok := res.Errs[RunStepName]; ok {
+			cr.Message = e.Error()
+		}
+	}
+	if cr.Verdict == VerdictWrongAnswer && tc.visibility() != VisibilityHidden {
+		cr.Diff = diffLines(outData, res.Outs[RunStepName])
+	}
+	meta, ok := res.Metas[RunStepName]
+	if !ok {
+		cr.Message = fmt.Sprintf("the metadata of test case %s is missing", tc.Name)
+	} else {
+		cr.ExitCode = meta.ExitCode
+		cr.Time = meta.Time
+		cr.WallTime = meta.TimeWall
+		cr.Memory = meta.MaxRSS
+		if cr.Verdict == VerdictRuntimeError {
+			cr.Message = memoryMessage(cr.Message, meta, runLimit.Memory)
+		}
+	}
 
-		return rep, nil
+	return caseOutcome{result: cr}
+}
+
+func runCustom(custom *CustomVerification, sub Submission, srcDir, stepOutDir string) (*Report, error) {
+	codeFiles, codeRefs, err := loadSubmission(sub, "./"+codeFileName)
+	if err != nil {
+		return &Report{
+			Verdict:  VerdictInternalError,
+			Message:  fmt.Sprintf("failed to get code file, path:%s, err: %s", sub.OssPath, err),
+			MaxScore: 1,
+		}, nil
 	}
 
 	id, err := idDispatcher.Get()
@@ -215,60 +397,105 @@ func runCustom(custom *CustomVerification, codePath string, srcDir, stepOutDir s
 		return nil, fmt.Errorf("failed to get action files, err: %w", err)
 	}
 
+	step := custom.Action.ToStep()
+	step.FileRefs = append(codeRefs, step.FileRefs...)
 	pl := &pipeline.Pipeline{
 		Steps: []pipeline.Step{
-			*custom.Action.ToStep(),
+			*step,
 		},
-		Files: append(files, pipeline.File{
-			Name:    "code",
-			Content: codeData,
-		}),
+		Files: append(files, codeFiles...),
 	}
 
-	res, msg, pass, err := execute(id, pl, stepOutDir)
+	var rep *Report
+	err = executeThen(id, pl, stepOutDir, func(res *pipeline.Result, readFile func(name string) ([]byte, error)) error {
+		rep = customReport(res, readFile, custom.ResultFormat, stepOutDir)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	if len(res.Errs) > 0 {
-		rep.Pass = false
-		var msgs []string
-		for step, e := range res.Errs {
-			msgs = append(msgs, fmt.Sprintf("step %s error: %s.", step, e))
-		}
-		rep.Message = strings.Join(msgs, "\n")
 
-		return rep, nil
+	return rep, nil
+}
+
+// withRunLimit copies the steps since they are shared by all cases
+func withRunLimit(steps []pipeline.Step, limit *pipeline.Limit) []pipeline.Step {
+	res := make([]pipeline.Step, len(steps))
+	copy(res, steps)
+	for i := range res {
+		if res[i].Name == RunStepName {
+			res[i].Limit = limit
+		}
 	}
-	rep.Pass = pass
-	rep.Message = msg
 
-	return rep, nil
+	return res
 }
 
-func validate(vf *Verification) error {
+func validate(vf *Verification) (*RuntimeSpec, error) {
 	if vf == nil {
-		return errors.New("verification cannot be empty")
-	}
-	if vf.Runtime != Runtime {
-		return errors.New("runtime mismatch")
+		return nil, errors.New("verification cannot be empty")
 	}
 	if vf.Name == "" {
-		return errors.New("verification name cannot be empty")
+		return nil, errors.New("verification name cannot be empty")
+	}
+	spec, ok := Lookup(vf.GetRuntime())
+	if !ok {
+		return nil, fmt.Errorf("runtime %s is not registered", vf.GetRuntime())
+	}
+	if vf.Code != nil {
+		if err := vf.Code.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if vf.Custom != nil {
+		if err := vf.Custom.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if vf.Suite != nil {
+		if err := vf.Suite.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if vf.Lint != nil {
+		if err := vf.Lint.Validate(); err != nil {
+			return nil, err
+		}
 	}
 
-	return nil
+	return spec, nil
 }
 
-func SetOssClient(c *oss.Client) {
-	ossClient = c
+// SetStorage sets the storage of the files of the verifications and the submissions
+func SetStorage(s oss.Storage) {
+	storage = s
 }
 
-func execute(id int, pl *pipeline.Pipeline, stepOutDir string) (
+// execute runs the pipeline in a new box, and reads the files of the box named by outFiles if they exist
+func execute(id int, pl *pipeline.Pipeline, stepOutDir string, outFiles ...string) (
 	res *pipeline.Result,
-	message string,
-	pass bool,
+	out map[string][]byte,
 	err error,
 ) {
+	err = executeThen(id, pl, stepOutDir, func(r *pipeline.Result, readFile func(name string) ([]byte, error)) error {
+		res = r
+		out = make(map[string][]byte, len(outFiles))
+		for _, name := range outFiles {
+			if data, e := readFile(name); e == nil {
+				out[name] = data
+			}
+		}
+
+		return nil
+	})
+
+	return
+}
+
+// executeThen runs the pipeline in a new box, and calls then with the result before the box is cleaned,
+// so that the files of the box can be read
+func executeThen(id int, pl *pipeline.Pipeline, stepOutDir string,
+	then func(res *pipeline.Result, readFile func(name string) ([]byte, error)) error) (err error) {
 	executor, err := pipeline.NewExecutor(id)
 	if err != nil {
 		return
@@ -278,24 +505,21 @@ func execute(id int, pl *pipeline.Pipeline, stepOutDir string) (
 			err = e
 		}
 	}(executor)
-	res, err = executor.Exec(*pl)
+	res, err := executor.Exec(*pl)
 	if err != nil {
-		return
+		// a failed step is part of the result, anything else is an internal error
+		var stepErr *pipeline.StepError
+		if !errors.As(err, &stepErr) {
+			return
+		}
+		err = nil
 	}
 
 	if err = StepOutToOSS(executor.StepOutDir(), stepOutDir); err != nil {
 		return
 	}
-	if data, e := executor.ReadFile("pass"); e == nil && strings.TrimSpace(string(data)) == "true" {
-		pass = true
-	} else {
-		pass = false
-	}
 
-	file, _ := executor.ReadFile("message")
-	message = string(file)
-
-	return
+	return then(res, executor.ReadFile)
 }
 
 func StepOutToOSS(localDir, ossDir string) error {
@@ -308,7 +532,7 @@ func StepOutToOSS(localDir, ossDir string) error {
 			continue
 		}
 		name := file.Name()
-		if err = ossClient.PutLocalTextFile(context.Background(), path.Join(localDir, name), path.Join(ossDir, name)); err != nil {
+		if err = oss.PutLocalTextFile(context.Background(), storage, path.Join(localDir, name), path.Join(ossDir, name)); err != nil {
 			return err
 		}
 	}
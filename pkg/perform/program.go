@@ -0,0 +1,155 @@
+package perform
+
+import (
+	"fmt"
+
+	"github.com/vincent-vinf/code-validator/pkg/pipeline"
+	"github.com/vincent-vinf/code-validator/pkg/types"
+)
+
+// Program is a single source file written by the author of the verification
+type Program struct {
+	// Runtime of the program, the default is the runtime of the verification
+	Runtime string         `json:"runtime,omitempty"`
+	Version string         `json:"version,omitempty"`
+	Compile *CompileOption `json:"compile,omitempty"`
+	Source  File           `json:"source"`
+}
+
+// ProgramRuntimes returns the runtimes of the programs of the author that are built by the actuator
+// of the verification, such as the special judge, the generator and the input validator
+func (c *CodeVerification) ProgramRuntimes(def types.Runtime) []types.Runtime {
+	var res []types.Runtime
+	if c.Judge != nil {
+		res = append(res, c.Judge.getRuntime())
+	}
+	if c.Generator != nil {
+		res = append(res, c.Generator.Generator.getRuntime(def), c.Generator.Solution.getRuntime(def))
+	}
+	if c.Validator != nil {
+		res = append(res, c.Validator.getRuntime(def))
+	}
+
+	return res
+}
+
+func (p *Program) getRuntime(def types.Runtime) types.Runtime {
+	if p.Runtime == "" {
+		return def
+	}
+
+	return types.Runtime{
+		Lang:    p.Runtime,
+		Version: p.Version,
+	}
+}
+
+type programRun struct {
+	Name  string
+	Args  []string
+	Input []byte
+}
+
+type programResult struct {
+	// Out is the stdout of the run, which is the data produced by the program
+	Out []byte
+	// Log is the combined stdout and stderr of the run, such as the reason of a rejected input
+	Log []byte
+	// Err is the failure of the run, such as a non-zero exit code
+	Err error
+}
+
+// runProgram compiles the program once and runs it for every run in the same box,
+// it returns the result of every run, or a message when the program cannot be compiled
+func runProgram(id int, p *Program, def types.Runtime, source []byte, runs []programRun,
+	limit Limit, stepOutDir, stepName string) (results []programResult, message string, err error) {
+	spec, ok := Lookup(p.getRuntime(def))
+	if !ok {
+		return nil, "", fmt.Errorf("runtime %s of the %s program is not registered", p.getRuntime(def), stepName)
+	}
+	if !spec.Available() {
+		return nil, fmt.Sprintf("runtime %s is not installed on the actuator", spec.Runtime), nil
+	}
+	templates, err := spec.Templates(p.Compile, "")
+	if err != nil {
+		return nil, "", err
+	}
+	run := templates[len(templates)-1]
+	sourceRef := pipeline.FileRef{
+		DataRef: pipeline.DataRef{
+			ExternalRef: &pipeline.ExternalRef{FileName: stepName},
+		},
+		Path: spec.Source,
+	}
+
+	pl := &pipeline.Pipeline{
+		Templates: templates,
+		Files: []pipeline.File{
+			{
+				Name:    stepName,
+				Content: source,
+			},
+		},
+	}
+	if spec.Compile != nil {
+		pl.Steps = append(pl.Steps, pipeline.Step{
+			Name:     CompileStepName,
+			Template: CompileStepName,
+			FileRefs: []pipeline.FileRef{sourceRef},
+		})
+	}
+	runLimit := spec.runLimit(limit)
+	for i, r := range runs {
+		t := run
+		t.Args = append(append([]string(nil), run.Args...), r.Args...)
+		inputName := fmt.Sprintf("%s-input-%d", stepName, i)
+		step := pipeline.Step{
+			Name:           fmt.Sprintf("%s-%d", stepName, i),
+			InlineTemplate: &t,
+			InputRef: &pipeline.DataRef{
+				ExternalRef: &pipeline.ExternalRef{FileName: inputName},
+			},
+			ContinueOnFail: true,
+			LogMate:        true,
+			Limit:          runLimit,
+		}
+		if spec.Compile == nil && i == 0 {
+			step.FileRefs = []pipeline.FileRef{sourceRef}
+		}
+		pl.Steps = append(pl.Steps, step)
+		pl.Files = append(pl.Files, pipeline.File{
+			Name:    inputName,
+			Content: r.Input,
+		})
+	}
+
+	res, _, err := execute(id, pl, stepOutDir)
+	if err != nil {
+		return nil, "", err
+	}
+	if e, ok := res.Errs[CompileStepName]; ok {
+		return nil, compileMessage(res.Outs[CompileStepName], e), nil
+	}
+	results = make([]programResult, len(runs))
+	for i := range runs {
+		name := fmt.Sprintf("%s-%d", stepName, i)
+		results[i] = programResult{
+			Out: res.Stdouts[name],
+			Log: res.Outs[name],
+			Err: res.Errs[name],
+		}
+	}
+
+	return results, "", nil
+}
+
+// failedRun returns the message of the first failed run, or empty if all of them succeed
+func failedRun(runs []programRun, results []programResult) string {
+	for i := range results {
+		if results[i].Err != nil {
+			return fmt.Sprintf("failed on case %s: %s", runs[i].Name, results[i].Err)
+		}
+	}
+
+	return ""
+}
@@ -1,5 +1,3 @@
-//go:build python
-
 package perform
 
 import (
@@ -7,39 +5,32 @@ import (
 	"github.com/vincent-vinf/code-validator/pkg/types"
 )
 
-const (
-	Runtime = types.PythonRuntime
-)
-
-func GetCodeTemplates() []pipeline.Template {
-	return []pipeline.Template{
-		{
-			Name: RunStepName,
-			Cmd:  "/usr/local/bin/python",
+func init() {
+	Register(&RuntimeSpec{
+		Runtime: types.Runtime{Lang: types.PythonRuntime},
+		Source:  "./main.py",
+		Run: pipeline.Template{
+			Cmd: "/usr/local/bin/python",
 			Args: []string{
 				"./main.py",
 			},
 		},
-	}
-}
-
-func GetCodeSteps() []pipeline.Step {
-	return []pipeline.Step{
-		{
-			Name:     RunStepName,
-			Template: RunStepName,
-			LogMate:  true,
-			InputRef: &pipeline.DataRef{
-				ExternalRef: &pipeline.ExternalRef{FileName: "input"},
-			},
-			FileRefs: []pipeline.FileRef{
-				{
-					DataRef: pipeline.DataRef{
-						ExternalRef: &pipeline.ExternalRef{FileName: "code"},
-					},
-					Path: "./main.py",
+		TimeMultiplier: 3,
+		Requires:       []string{"/usr/local/bin/python"},
+		Deps: &DependencySpec{
+			Lockfile: "requirements.txt",
+			CacheDir: "/wheels",
+			Install: pipeline.Template{
+				Cmd: "/usr/local/bin/python",
+				Args: []string{
+					"-m", "pip", "install", "--no-index", "--find-links", "/wheels", "--no-cache-dir",
+					"--disable-pip-version-check", "--target", "./.deps", "-r", "./requirements.txt",
 				},
 			},
+			EnvDir: "./.deps",
+			Env: map[string]string{
+				"PYTHONPATH": depsMountPoint,
+			},
 		},
-	}
+	})
 }
@@ -0,0 +1,231 @@
+package perform
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vincent-vinf/code-validator/pkg/pipeline"
+	"github.com/vincent-vinf/code-validator/pkg/types"
+	"github.com/vincent-vinf/code-validator/pkg/util/config"
+)
+
+var (
+	registry     = make(map[types.Runtime]*RuntimeSpec)
+	registryLock sync.RWMutex
+)
+
+// RuntimeSpec describes how the code of a runtime is compiled and run
+type RuntimeSpec struct {
+	types.Runtime
+	// Source is the path in the box that the code is written to
+	Source string
+	// Compile returns the compile template, it is nil for interpreted runtimes
+	Compile func(opt *CompileOption) (*pipeline.Template, error)
+	// Artifacts are the paths written by the compile step that the run step needs, with them the code is compiled
+	// once and the artifacts are copied into the box of every case, otherwise every case compiles the code
+	Artifacts []string
+	Run       pipeline.Template
+	// MemoryEnv passes the memory limit in KB to the run by the variable, for the runtimes that limit their own memory
+	// since they reserve more address space than they use, such as the heap of the JVM
+	MemoryEnv string
+	// TimeMultiplier scales the time limit of verifications, 0 means 1
+	TimeMultiplier float64
+	// Requires are the paths on the host that must exist to serve the runtime
+	Requires []string
+	// Deps installs the dependencies of projects, nil if the runtime does not support it
+	Deps *DependencySpec
+}
+
+// Register adds a runtime to the registry, a runtime registered later replaces the earlier one
+func Register(spec *RuntimeSpec) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	registry[spec.Runtime] = spec
+}
+
+func Lookup(rt types.Runtime) (*RuntimeSpec, bool) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	spec, ok := registry[rt]
+
+	return spec, ok
+}
+
+// Runtimes returns all registered runtimes ordered by language and version
+func Runtimes() []*RuntimeSpec {
+	registryLock.RLock()
+	res := make([]*RuntimeSpec, 0, len(registry))
+	for _, spec := range registry {
+		res = append(res, spec)
+	}
+	registryLock.RUnlock()
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Lang != res[j].Lang {
+			return res[i].Lang < res[j].Lang
+		}
+
+		return res[i].Version < res[j].Version
+	})
+
+	return res
+}
+
+// Langs returns the languages of the registered runtimes in order
+func Langs() []string {
+	var res []string
+	for _, spec := range Runtimes() {
+		if len(res) == 0 || res[len(res)-1] != spec.Lang {
+			res = append(res, spec.Lang)
+		}
+	}
+
+	return res
+}
+
+// AvailableRuntimes returns the registered runtimes that can be served by this host
+func AvailableRuntimes() []*RuntimeSpec {
+	var res []*RuntimeSpec
+	for _, spec := range Runtimes() {
+		if spec.Available() {
+			res = append(res, spec)
+		}
+	}
+
+	return res
+}
+
+// LoadRuntimes registers the runtimes declared in the config
+func LoadRuntimes(cfgs []config.Runtime) error {
+	for i := range cfgs {
+		spec, err := specFromConfig(cfgs[i])
+		if err != nil {
+			return err
+		}
+		Register(spec)
+	}
+
+	return nil
+}
+
+func specFromConfig(cfg config.Runtime) (*RuntimeSpec, error) {
+	if cfg.Lang == "" {
+		return nil, errors.New("runtime lang cannot be empty")
+	}
+	if cfg.Source == "" || cfg.Run.Cmd == "" {
+		return nil, fmt.Errorf("runtime %s requires source and run", cfg.Lang)
+	}
+	spec := &RuntimeSpec{
+		Runtime: types.Runtime{
+			Lang:    cfg.Lang,
+			Version: cfg.Version,
+		},
+		Source:         cfg.Source,
+		Artifacts:      cfg.Artifacts,
+		Run:            templateFromConfig(cfg.Run),
+		MemoryEnv:      cfg.MemoryEnv,
+		TimeMultiplier: cfg.TimeMultiplier,
+		Requires:       cfg.Requires,
+	}
+	if cfg.Compile != nil {
+		compile := templateFromConfig(*cfg.Compile)
+		spec.Compile = func(*CompileOption) (*pipeline.Template, error) {
+			return &compile, nil
+		}
+	}
+
+	return spec, nil
+}
+
+func templateFromConfig(cfg config.Template) pipeline.Template {
+	return pipeline.Template{
+		Cmd:  cfg.Cmd,
+		Args: cfg.Args,
+		Env:  cfg.Env,
+	}
+}
+
+func (s *RuntimeSpec) Available() bool {
+	for _, p := range s.Requires {
+		if _, err := os.Stat(p); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Templates returns the templates of the runtime, entry is the entry point of a project and empty for a single file
+func (s *RuntimeSpec) Templates(opt *CompileOption, entry string) ([]pipeline.Template, error) {
+	run := withEntry(s.Run, s.Source, entry)
+	run.Name = RunStepName
+	if s.Compile == nil {
+		return []pipeline.Template{run}, nil
+	}
+	compile, err := s.Compile(opt)
+	if err != nil {
+		return nil, err
+	}
+	c := withEntry(*compile, s.Source, entry)
+	c.Name = CompileStepName
+
+	return []pipeline.Template{c, run}, nil
+}
+
+// Steps writes the code to the box and runs it with the input of the case
+func (s *RuntimeSpec) Steps(codeRefs []pipeline.FileRef) []pipeline.Step {
+	run := runStep()
+	if s.Compile == nil {
+		run.FileRefs = codeRefs
+
+		return []pipeline.Step{run}
+	}
+
+	return []pipeline.Step{
+		{
+			Name:     CompileStepName,
+			Template: CompileStepName,
+			FileRefs: codeRefs,
+		},
+		run,
+	}
+}
+
+// runStep runs the code with the input of the case
+func runStep() pipeline.Step {
+	return pipeline.Step{
+		Name:     RunStepName,
+		Template: RunStepName,
+		LogMate:  true,
+		InputRef: &pipeline.DataRef{
+			ExternalRef: &pipeline.ExternalRef{FileName: "input"},
+		},
+	}
+}
+
+// runLimit converts the limit of a case to the limit of the run step, the network is off while grading
+func (s *RuntimeSpec) runLimit(limit Limit) *pipeline.Limit {
+	return &pipeline.Limit{
+		EnableNetWork: false,
+		Time:          s.scaleTime(limit.Time),
+		WallTime:      s.scaleTime(limit.WallTime),
+		Memory:        limit.Memory,
+		MemoryEnv:     s.MemoryEnv,
+	}
+}
+
+// scaleTime converts a limit in seconds to the limit of the runtime
+func (s *RuntimeSpec) scaleTime(seconds float64) time.Duration {
+	m := s.TimeMultiplier
+	if m <= 0 {
+		m = 1
+	}
+
+	return time.Duration(seconds * m * float64(time.Second))
+}
@@ -0,0 +1,77 @@
+package perform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vincent-vinf/code-validator/pkg/pipeline"
+	"github.com/vincent-vinf/code-validator/pkg/types"
+)
+
+var (
+	rustEditions = []string{"2015", "2018", "2021"}
+	rustEnv      = map[string]string{
+		"PATH":        "/usr/local/cargo/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+		"RUSTUP_HOME": "/usr/local/rustup",
+		// the cargo home of the image is read-only in the sandbox
+		"CARGO_HOME": "/tmp/cargo",
+	}
+)
+
+func init() {
+	Register(&RuntimeSpec{
+		Runtime:   types.Runtime{Lang: types.RustRuntime},
+		Source:    "./main.rs",
+		Compile:   rustCompile,
+		Artifacts: []string{"./main"},
+		Run: pipeline.Template{
+			Cmd: "./main",
+		},
+		TimeMultiplier: 1,
+		Requires:       []string{"/usr/local/cargo/bin/rustc"},
+	})
+}
+
+func rustCompile(opt *CompileOption) (*pipeline.Template, error) {
+	args, err := rustcArgs(opt)
+	if err != nil {
+		return nil, err
+	}
+	// a project with a Cargo.toml is built by cargo, its dependencies must be vendored
+	script := fmt.Sprintf(`set -e
+if [ -f ./Cargo.toml ]; then
+  cargo build --offline --release --quiet
+  find ./target/release -maxdepth 1 -type f -perm -u+x -exec cp {} ./main \;
+else
+  rustc %s -o ./main ./main.rs
+fi`, strings.Join(args, " "))
+
+	return &pipeline.Template{
+		Cmd:  "/bin/sh",
+		Args: []string{"-c", script},
+		Env:  rustEnv,
+	}, nil
+}
+
+func rustcArgs(opt *CompileOption) ([]string, error) {
+	edition, optimize := "2021", defaultOptimize
+	if opt != nil {
+		if opt.Std != "" {
+			edition = opt.Std
+		}
+		if opt.Optimize != "" {
+			optimize = opt.Optimize
+		}
+	}
+	if !contains(rustEditions, edition) {
+		return nil, fmt.Errorf("unsupported edition %s, allowed: %v", edition, rustEditions)
+	}
+	if !contains(optimizeLevels, optimize) {
+		return nil, fmt.Errorf("unsupported optimisation level %s, allowed: %v", optimize, optimizeLevels)
+	}
+
+	return []string{
+		"--edition", edition,
+		"-C", "opt-level=" + strings.ToLower(strings.TrimPrefix(optimize, "O")),
+	}, nil
+}
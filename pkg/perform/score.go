@@ -0,0 +1,166 @@
+package perform
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// AggregationSum gives the points of a group in proportion to the weight of the passed cases
+	AggregationSum = "sum"
+	// AggregationMin gives the points of a group by its worst case, so all of its cases must pass
+	AggregationMin = "min"
+
+	defaultWeight = 1
+)
+
+// CaseGroup groups the cases with the same TestCase.Group
+type CaseGroup struct {
+	Name string `json:"name"`
+	// Points of the group, the default is the sum of the weights of its cases
+	Points float64 `json:"points,omitempty"`
+	// Aggregation is AggregationSum or AggregationMin, the default is AggregationMin
+	Aggregation string `json:"aggregation,omitempty"`
+}
+
+type GroupResult struct {
+	Name     string  `json:"name"`
+	Score    float64 `json:"score"`
+	MaxScore float64 `json:"maxScore"`
+}
+
+func (tc *TestCase) weight() float64 {
+	if tc.Weight > 0 {
+		return tc.Weight
+	}
+
+	return defaultWeight
+}
+
+func (c *CodeVerification) validateGroups() error {
+	if c.Generator != nil {
+		// the groups are checked before the cases are generated
+		return validateGroups(c.Generator.testCases(""), c.Groups)
+	}
+
+	return validateGroups(c.Cases, c.Groups)
+}
+
+// validateGroups checks that every group is well defined and used by at least one case
+func validateGroups(cases []TestCase, caseGroups []CaseGroup) error {
+	groups := make(map[string]bool, len(caseGroups))
+	for _, g := range caseGroups {
+		if g.Name == "" {
+			return fmt.Errorf("the name of a case group cannot be empty")
+		}
+		if groups[g.Name] {
+			return fmt.Errorf("duplicate case group %s", g.Name)
+		}
+		switch g.Aggregation {
+		case "", AggregationSum, AggregationMin:
+		default:
+			return fmt.Errorf("unsupported aggregation %s of case group %s", g.Aggregation, g.Name)
+		}
+		groups[g.Name] = true
+	}
+	used := make(map[string]bool, len(groups))
+	for _, tc := range cases {
+		if tc.Group != "" && !groups[tc.Group] {
+			return fmt.Errorf("case %s belongs to an undefined group %s", tc.Name, tc.Group)
+		}
+		used[tc.Group] = true
+	}
+	for name := range groups {
+		if !used[name] {
+			return fmt.Errorf("case group %s has no cases", name)
+		}
+	}
+
+	return nil
+}
+
+// MaxScore is the score when all cases pass
+func (c *CodeVerification) MaxScore() float64 {
+	_, max := scoreCases(c.Cases, c.Groups, nil)
+
+	return max
+}
+
+// Score computes the score of the results of the cases, cases without a result earn nothing.
+// Cases outside of any group earn their weight when passed.
+func (c *CodeVerification) Score(results []CaseResult) (float64, []GroupResult) {
+	groups, _ := scoreCases(c.Cases, c.Groups, results)
+
+	return sumGroups(groups), groups
+}
+
+func sumGroups(groups []GroupResult) float64 {
+	var score float64
+	for _, g := range groups {
+		score += g.Score
+	}
+
+	return score
+}
+
+func scoreCases(cases []TestCase, caseGroups []CaseGroup, results []CaseResult) ([]GroupResult, float64) {
+	// credit is the fraction of the weight earned by each case
+	credit := make(map[string]float64, len(results))
+	for _, r := range results {
+		switch {
+		case r.Pass:
+			credit[r.Name] = 1
+		case r.Verdict == VerdictPartial:
+			credit[r.Name] = r.Partial
+		}
+	}
+
+	var (
+		groups   []GroupResult
+		maxScore float64
+	)
+	// the ungrouped cases are collected in a group without name
+	ungrouped := GroupResult{}
+	for i := range cases {
+		if cases[i].Group != "" {
+			continue
+		}
+		ungrouped.MaxScore += cases[i].weight()
+		ungrouped.Score += cases[i].weight() * credit[cases[i].Name]
+	}
+	if ungrouped.MaxScore > 0 {
+		groups = append(groups, ungrouped)
+		maxScore += ungrouped.MaxScore
+	}
+
+	for _, g := range caseGroups {
+		var total, earned float64
+		minCredit := 1.0
+		for i := range cases {
+			if cases[i].Group != g.Name {
+				continue
+			}
+			total += cases[i].weight()
+			earned += cases[i].weight() * credit[cases[i].Name]
+			minCredit = math.Min(minCredit, credit[cases[i].Name])
+		}
+		res := GroupResult{
+			Name:     g.Name,
+			MaxScore: g.Points,
+		}
+		if res.MaxScore <= 0 {
+			res.MaxScore = total
+		}
+		switch {
+		case total == 0:
+		case g.Aggregation == AggregationSum:
+			res.Score = res.MaxScore * earned / total
+		default:
+			res.Score = res.MaxScore * minCredit
+		}
+		groups = append(groups, res)
+		maxScore += res.MaxScore
+	}
+
+	return groups, maxScore
+}
@@ -0,0 +1,70 @@
+package perform
+
+import "testing"
+
+func TestCodeVerificationScore(t *testing.T) {
+	code := &CodeVerification{
+		Groups: []CaseGroup{
+			{Name: "small", Points: 30, Aggregation: AggregationMin},
+			{Name: "large", Points: 60, Aggregation: AggregationSum},
+		},
+		Cases: []TestCase{
+			{Name: "sample", Weight: 10},
+			{Name: "s1", Group: "small"},
+			{Name: "s2", Group: "small"},
+			{Name: "l1", Group: "large", Weight: 1},
+			{Name: "l2", Group: "large", Weight: 2},
+		},
+	}
+	if err := code.validateGroups(); err != nil {
+		t.Fatal(err)
+	}
+	if got := code.MaxScore(); got != 100 {
+		t.Errorf("MaxScore() = %v, want 100", got)
+	}
+
+	score, groups := code.Score([]CaseResult{
+		{Name: "sample", Pass: true},
+		{Name: "s1", Pass: true},
+		{Name: "s2", Pass: false},
+		{Name: "l1", Pass: false},
+		{Name: "l2", Pass: true},
+	})
+	if score != 50 {
+		t.Errorf("Score() = %v, want 50", score)
+	}
+	want := []GroupResult{
+		{Score: 10, MaxScore: 10},
+		{Name: "small", Score: 0, MaxScore: 30},
+		{Name: "large", Score: 40, MaxScore: 60},
+	}
+	if len(groups) != len(want) {
+		t.Fatalf("got %d groups, want %d", len(groups), len(want))
+	}
+	for i := range want {
+		if groups[i] != want[i] {
+			t.Errorf("group %d = %+v, want %+v", i, groups[i], want[i])
+		}
+	}
+}
+
+func TestValidateGroups(t *testing.T) {
+	tests := []struct {
+		name string
+		code *CodeVerification
+	}{
+		{"undefined group", &CodeVerification{Cases: []TestCase{{Name: "1", Group: "g"}}}},
+		{"empty group", &CodeVerification{Groups: []CaseGroup{{Name: "g"}}}},
+		{"unknown aggregation", &CodeVerification{
+			Groups: []CaseGroup{{Name: "g", Aggregation: "max"}},
+			Cases:  []TestCase{{Name: "1", Group: "g"}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.code.validateGroups(); err == nil {
+				t.Error("validateGroups() expects an error")
+			}
+		})
+	}
+}
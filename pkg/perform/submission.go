@@ -0,0 +1,99 @@
+package perform
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/vincent-vinf/code-validator/pkg/pipeline"
+	"github.com/vincent-vinf/code-validator/pkg/util/archive"
+)
+
+const (
+	codeFileName = "code"
+)
+
+// Submission is the code submitted by the user
+type Submission struct {
+	// OssPath is the path of the code file, or of the archive of a project
+	OssPath string `json:"ossPath"`
+	// Archive is the format of the archive, empty for a single file
+	Archive string `json:"archive,omitempty"`
+}
+
+func (s Submission) IsArchive() bool {
+	return s.Archive != ""
+}
+
+// loadSubmission reads the submission and returns its files with the refs that write them to the box.
+// A single file is written to singlePath, a project is unpacked into the working directory.
+func loadSubmission(sub Submission, singlePath string) ([]pipeline.File, []pipeline.FileRef, error) {
+	data, err := ReadOSSFile(sub.OssPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !sub.IsArchive() {
+		return []pipeline.File{
+			{Name: codeFileName, Content: data},
+		}, []pipeline.FileRef{
+			{
+				DataRef: pipeline.DataRef{
+					ExternalRef: &pipeline.ExternalRef{FileName: codeFileName},
+				},
+				Path: singlePath,
+			},
+		}, nil
+	}
+
+	entries, err := archive.Extract(data, sub.Archive)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to unpack the submission: %w", err)
+	}
+	files := make([]pipeline.File, 0, len(entries))
+	refs := make([]pipeline.FileRef, 0, len(entries))
+	for _, e := range entries {
+		name := path.Join(codeFileName, e.Path)
+		files = append(files, pipeline.File{Name: name, Content: e.Content})
+		refs = append(refs, pipeline.FileRef{
+			DataRef: pipeline.DataRef{
+				ExternalRef: &pipeline.ExternalRef{FileName: name},
+			},
+			Path: "./" + e.Path,
+		})
+	}
+
+	return files, refs, nil
+}
+
+// entryPoint returns the entry point of a project in the box, the source path of the runtime is the default
+func entryPoint(spec *RuntimeSpec, entry string, refs []pipeline.FileRef) (string, error) {
+	if entry == "" {
+		entry = spec.Source
+	}
+	p, err := archive.CheckPath(entry)
+	if err != nil {
+		return "", err
+	}
+	p = "./" + p
+	for _, ref := range refs {
+		if ref.Path == p {
+			return p, nil
+		}
+	}
+
+	return "", fmt.Errorf("the entry point %s is not in the submission", entry)
+}
+
+// withEntry replaces the source path of the runtime in the template with the entry point
+func withEntry(t pipeline.Template, source, entry string) pipeline.Template {
+	if entry == "" || entry == source {
+		return t
+	}
+	args := make([]string, len(t.Args))
+	for i := range t.Args {
+		args[i] = strings.ReplaceAll(t.Args[i], source, entry)
+	}
+	t.Args = args
+
+	return t
+}
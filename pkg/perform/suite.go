@@ -0,0 +1,204 @@
+package perform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vincent-vinf/code-validator/pkg/pipeline"
+	"github.com/vincent-vinf/code-validator/pkg/util/junit"
+)
+
+const (
+	FrameworkPytest = "pytest"
+	FrameworkJest   = "jest"
+	FrameworkGoTest = "go-test"
+
+	SuiteStepName = "suite"
+
+	defaultSuiteReport = "./report.xml"
+)
+
+// frameworks are the commands that write a JUnit XML report to ./report.xml,
+// the tools must be installed in the image or in the dependencies of the project
+var frameworks = map[string]pipeline.Template{
+	FrameworkPytest: {
+		Cmd:  "/usr/local/bin/python",
+		Args: []string{"-m", "pytest", "-q", "--junitxml=" + defaultSuiteReport},
+	},
+	FrameworkJest: {
+		Cmd:  "/bin/sh",
+		Args: []string{"-c", "npx --no-install jest --ci --reporters=default --reporters=jest-junit"},
+		Env:  map[string]string{"JEST_JUNIT_OUTPUT_FILE": defaultSuiteReport},
+	},
+	FrameworkGoTest: {
+		Cmd: "/bin/sh",
+		Args: []string{"-c", goModInit +
+			" && /usr/local/go/bin/go test -v ./... 2>&1 | go-junit-report -set-exit-code > " + defaultSuiteReport},
+	},
+}
+
+// SuiteVerification runs a unit test suite against the code, every testcase of the report is a case
+type SuiteVerification struct {
+	// Framework selects the command of a known framework
+	Framework string `json:"framework,omitempty"`
+	// Command is a shell command that writes the report, it takes precedence over Framework
+	Command string `json:"command,omitempty"`
+	// Report is the path of the JUnit XML report written by Command, the default is ./report.xml
+	Report string `json:"report,omitempty"`
+	// Files are the test files, which are written to the box after the code
+	Files []File `json:"files"`
+	Limit *Limit `json:"limit,omitempty"`
+	// Tests are the expected testcases by the full name of the report, such as tests.test_main.test_add.
+	// Only their Name, Weight and Group are used, a test missing from the report fails.
+	// Without them every testcase of the report is worth 1.
+	Tests  []TestCase  `json:"tests,omitempty"`
+	Groups []CaseGroup `json:"groups,omitempty"`
+}
+
+func (s *SuiteVerification) Validate() error {
+	if s.Command == "" {
+		if _, ok := frameworks[s.Framework]; !ok {
+			return fmt.Errorf("unsupported framework %s, a command is required", s.Framework)
+		}
+	}
+
+	return validateGroups(s.Tests, s.Groups)
+}
+
+func (s *SuiteVerification) template() pipeline.Template {
+	t := frameworks[s.Framework]
+	if s.Command != "" {
+		t = pipeline.Template{
+			Cmd:  "/bin/sh",
+			Args: []string{"-c", s.Command},
+		}
+	}
+	t.Name = RunStepName
+
+	return t
+}
+
+func (s *SuiteVerification) report() string {
+	if s.Command != "" && s.Report != "" {
+		return s.Report
+	}
+
+	return defaultSuiteReport
+}
+
+func runSuite(spec *RuntimeSpec, suite *SuiteVerification, sub Submission, srcDir, stepOutDir string) (*Report, error) {
+	rep := &Report{
+		Pass: true,
+	}
+	if len(suite.Tests) > 0 {
+		_, rep.MaxScore = scoreCases(suite.Tests, suite.Groups, nil)
+	}
+
+	tool := &toolRun{
+		stepName: SuiteStepName,
+		template: suite.template(),
+		files:    suite.Files,
+		limit:    suite.Limit,
+		report:   suite.report(),
+	}
+	out, err := runTool(spec, tool, rep, sub, srcDir, stepOutDir)
+	if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		return rep, nil
+	}
+	if !out.reported {
+		// the suite did not run, such as a syntax error in the code
+		rep.Pass = false
+		rep.Verdict = caseVerdict(out.res)
+		if rep.Verdict == VerdictAccepted || rep.Verdict == VerdictWrongAnswer {
+			rep.Verdict = VerdictRuntimeError
+		}
+		rep.Message = suiteMessage(out.res.Outs[RunStepName])
+
+		return rep, nil
+	}
+	tests, err := junit.Parse(out.report)
+	if err != nil {
+		rep.Pass = false
+		rep.Verdict = VerdictInternalError
+		rep.Message = fmt.Sprintf("failed to parse the report %s: %s", suite.report(), err)
+
+		return rep, nil
+	}
+
+	rep.Cases = suiteResults(suite.Tests, tests)
+	rep.Verdict = aggregateVerdict(rep.Cases)
+	rep.Pass = rep.Verdict == VerdictAccepted
+	if len(rep.Cases) == 0 {
+		rep.Pass = false
+		rep.Verdict = VerdictInternalError
+		rep.Message = "the report has no testcases"
+	}
+	cases := suite.Tests
+	if len(cases) == 0 {
+		for _, c := range rep.Cases {
+			cases = append(cases, TestCase{Name: c.Name})
+		}
+		_, rep.MaxScore = scoreCases(cases, nil, nil)
+	}
+	groups, _ := scoreCases(cases, suite.Groups, rep.Cases)
+	rep.Score, rep.Groups = sumGroups(groups), groups
+
+	return rep, nil
+}
+
+// suiteResults converts the testcases of the report, the expected tests missing from the report fail
+func suiteResults(expected []TestCase, tests []junit.TestCase) []CaseResult {
+	var res []CaseResult
+	found := make(map[string]bool, len(tests))
+	for _, t := range tests {
+		cr := CaseResult{
+			Name:    t.FullName(),
+			Verdict: VerdictAccepted,
+			Message: t.Message,
+			Time:    t.Time,
+		}
+		switch t.Status {
+		case junit.Failed:
+			cr.Verdict = VerdictWrongAnswer
+		case junit.Errored:
+			cr.Verdict = VerdictRuntimeError
+		case junit.Skipped:
+			cr.Verdict = VerdictWrongAnswer
+			if cr.Message == "" {
+				cr.Message = "skipped"
+			}
+		}
+		if len(cr.Message) > maxDiagnosticsLen {
+			cr.Message = cr.Message[:maxDiagnosticsLen] + "..."
+		}
+		cr.Pass = cr.Verdict == VerdictAccepted
+		found[cr.Name] = true
+		res = append(res, cr)
+	}
+	for _, tc := range expected {
+		if !found[tc.Name] {
+			res = append(res, CaseResult{
+				Name:    tc.Name,
+				Verdict: VerdictWrongAnswer,
+				Message: "the test is missing from the report",
+			})
+		}
+	}
+
+	return res
+}
+
+func suiteMessage(out []byte) string {
+	msg := "the test suite did not write a report"
+	if len(out) > 0 {
+		msg += ":\n" + strings.TrimSpace(string(out))
+	}
+	if len(msg) > maxDiagnosticsLen {
+		msg = msg[:maxDiagnosticsLen] + "..."
+	}
+
+	return msg
+}
@@ -0,0 +1,76 @@
+package perform
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/vincent-vinf/code-validator/pkg/util/junit"
+)
+
+func TestSuiteResults(t *testing.T) {
+	expected := []TestCase{{Name: "t.test_a", Weight: 2}, {Name: "t.test_b"}, {Name: "t.test_c"}}
+	res := suiteResults(expected, []junit.TestCase{
+		{Classname: "t", Name: "test_a", Status: junit.Passed},
+		{Classname: "t", Name: "test_b", Status: junit.Failed, Message: "assert 1 == 2"},
+	})
+	want := []struct {
+		name    string
+		verdict Verdict
+	}{
+		{"t.test_a", VerdictAccepted},
+		{"t.test_b", VerdictWrongAnswer},
+		{"t.test_c", VerdictWrongAnswer},
+	}
+	if len(res) != len(want) {
+		t.Fatalf("got %d results, want %d", len(res), len(want))
+	}
+	for i := range want {
+		if res[i].Name != want[i].name || res[i].Verdict != want[i].verdict {
+			t.Errorf("result %d = %s %s, want %s %s", i, res[i].Name, res[i].Verdict, want[i].name, want[i].verdict)
+		}
+	}
+
+	groups, max := scoreCases(expected, nil, res)
+	if score := sumGroups(groups); score != 2 || max != 4 {
+		t.Errorf("score = %v/%v, want 2/4", score, max)
+	}
+}
+
+func TestGoTestSingleFile(t *testing.T) {
+	for _, bin := range []string{"/usr/local/go/bin/go", "go-junit-report"} {
+		if _, err := exec.LookPath(bin); err != nil {
+			t.Skipf("%s is not installed", bin)
+		}
+	}
+	// a submission of a single file has no go.mod
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.go":      "package main\n\nfunc add(a, b int) int { return a + b }\n\nfunc main() {}\n",
+		"main_test.go": "package main\n\nimport \"testing\"\n\nfunc TestAdd(t *testing.T) {\n\tif add(1, 2) != 3 {\n\t\tt.Fail()\n\t}\n}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tpl := frameworks[FrameworkGoTest]
+	cmd := exec.Command(tpl.Cmd, tpl.Args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go test failed: %s, out: %s", err, out)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, defaultSuiteReport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests, err := junit.Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tests) != 1 || tests[0].Name != "TestAdd" || tests[0].Status != junit.Passed {
+		t.Errorf("tests = %+v", tests)
+	}
+}
@@ -0,0 +1,116 @@
+package perform
+
+import (
+	"fmt"
+
+	"github.com/vincent-vinf/code-validator/pkg/pipeline"
+	"github.com/vincent-vinf/code-validator/pkg/sandbox"
+)
+
+// toolRun runs a tool against the submission in a box, such as a test framework or a static analyzer,
+// and reads the report written by the tool
+type toolRun struct {
+	// stepName names the files of the tool
+	stepName string
+	template pipeline.Template
+	// files are written to the box after the code, such as the tests or the configuration of the tool
+	files  []File
+	limit  *Limit
+	report string
+}
+
+type toolResult struct {
+	res    *pipeline.Result
+	report []byte
+	// reported is false if the tool did not write the report
+	reported bool
+}
+
+// runTool runs the tool, the dependencies of a project are installed first.
+// When the submission cannot be loaded or its dependencies cannot be installed, the verdict is written to rep
+// and the result is nil.
+func runTool(spec *RuntimeSpec, tool *toolRun, rep *Report, sub Submission, srcDir, stepOutDir string) (
+	*toolResult, error) {
+	codeFiles, codeRefs, err := loadSubmission(sub, spec.Source)
+	if err != nil {
+		rep.Pass = false
+		rep.Verdict = VerdictInternalError
+		rep.Message = fmt.Sprintf("failed to get code file, path: %s, err: %s", sub.OssPath, err)
+
+		return nil, nil
+	}
+	toolFiles, err := ToPipelineFile(srcDir, tool.stepName, tool.files)
+	if err != nil {
+		return nil, err
+	}
+	refs := codeRefs
+	for i := range tool.files {
+		refs = append(refs, pipeline.FileRef{
+			DataRef: pipeline.DataRef{
+				ExternalRef: &pipeline.ExternalRef{
+					FileName: GetFileName(tool.stepName, tool.files[i].Path),
+				},
+			},
+			Path: tool.files[i].Path,
+		})
+	}
+
+	t := tool.template
+	t.Name = RunStepName
+	// the environment of the runtime is kept, such as the paths of the toolchain
+	env := make(map[string]string, len(spec.Run.Env)+len(t.Env))
+	for k, v := range spec.Run.Env {
+		env[k] = v
+	}
+	for k, v := range t.Env {
+		env[k] = v
+	}
+	t.Env = env
+	templates := []pipeline.Template{t}
+	var mounts []sandbox.Dir
+	if lockfile := findLockfile(spec, codeFiles, codeRefs); lockfile != nil {
+		envDir, out, err := installDependencies(spec, lockfile)
+		if err != nil {
+			rep.Pass = false
+			rep.Verdict = VerdictDependencyError
+			rep.Message = dependencyMessage(out, err)
+
+			return nil, nil
+		}
+		mounts = append(mounts, depsMount(envDir))
+		withDependencyEnv(spec, templates)
+	}
+
+	pl := &pipeline.Pipeline{
+		Steps: []pipeline.Step{
+			{
+				Name:     RunStepName,
+				Template: RunStepName,
+				FileRefs: refs,
+				// the tools exit with an error when a test fails or a problem is found
+				ContinueOnFail: true,
+				LogMate:        true,
+				Limit:          spec.runLimit(tool.limit.merge(nil)),
+			},
+		},
+		Templates: templates,
+		Files:     append(codeFiles, toolFiles...),
+		Mounts:    mounts,
+	}
+
+	id, err := idDispatcher.Get()
+	if err != nil {
+		// Too many verification items are running at the same time,
+		// an error is returned, and the upper layer will retry
+		return nil, fmt.Errorf("too many validations running at the same time: %w", err)
+	}
+	defer idDispatcher.Release(id)
+
+	res, out, err := execute(id, pl, stepOutDir, tool.report)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := out[tool.report]
+
+	return &toolResult{res: res, report: data, reported: ok}, nil
+}
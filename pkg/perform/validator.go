@@ -0,0 +1,93 @@
+package perform
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+const validateStepName = "validate"
+
+// InputCheck is the result of the input validator on the cases of a verification.
+// The validator reads the input of a case from the standard input, and rejects it by a non-zero exit code
+// with the reason as its output, such as the validators of testlib
+type InputCheck struct {
+	ValidatorHash string    `json:"validatorHash,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+	// Invalid are the cases rejected by the validator
+	Invalid []InvalidInput `json:"invalid,omitempty"`
+	// Message is the failure of the validator itself, such as a compile error
+	Message string `json:"message,omitempty"`
+}
+
+type InvalidInput struct {
+	Case    string `json:"case"`
+	Message string `json:"message,omitempty"`
+}
+
+// Valid reports whether the validator accepted every case
+func (c *InputCheck) Valid() bool {
+	return c.Message == "" && len(c.Invalid) == 0
+}
+
+// CheckInputs runs the input validator of the verification on the input of every case
+func CheckInputs(vf *Verification, srcDir string) (*InputCheck, error) {
+	if vf.Code == nil || vf.Code.Validator == nil {
+		return nil, fmt.Errorf("verification %s has no input validator", vf.Name)
+	}
+	code := vf.Code
+	source, err := ReadOSSFile(path.Join(srcDir, code.Validator.Source.OssPath))
+	if err != nil {
+		return nil, err
+	}
+	check := &InputCheck{
+		ValidatorHash: sourceHash(source),
+		CreatedAt:     time.Now(),
+	}
+	if len(code.Cases) == 0 {
+		return check, nil
+	}
+	runs := make([]programRun, 0, len(code.Cases))
+	for _, tc := range code.Cases {
+		in, err := ReadOSSFile(path.Join(srcDir, tc.In.OssPath))
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, programRun{Name: tc.Name, Input: in})
+	}
+
+	id, err := idDispatcher.Get()
+	if err != nil {
+		return nil, fmt.Errorf("too many validations running at the same time: %w", err)
+	}
+	defer idDispatcher.Release(id)
+
+	results, msg, err := runProgram(id, code.Validator, vf.GetRuntime(), source, runs, code.Limit.merge(nil),
+		path.Join(srcDir, validateStepName, vf.Name), validateStepName)
+	if err != nil {
+		return nil, err
+	}
+	if msg != "" {
+		check.Message = "validator " + msg
+		return check, nil
+	}
+	for i, r := range results {
+		if r.Err == nil {
+			continue
+		}
+		reason := strings.TrimSpace(string(r.Log))
+		if len(reason) > maxDiagnosticsLen {
+			reason = reason[:maxDiagnosticsLen] + "..."
+		}
+		if reason == "" {
+			reason = r.Err.Error()
+		}
+		check.Invalid = append(check.Invalid, InvalidInput{
+			Case:    runs[i].Name,
+			Message: reason,
+		})
+	}
+
+	return check, nil
+}
@@ -0,0 +1,144 @@
+package perform
+
+import (
+	"fmt"
+
+	"github.com/vincent-vinf/code-validator/pkg/pipeline"
+	"github.com/vincent-vinf/code-validator/pkg/sandbox"
+)
+
+type Verdict string
+
+const (
+	VerdictAccepted         Verdict = "AC"
+	VerdictWrongAnswer      Verdict = "WA"
+	VerdictPartial          Verdict = "PC"
+	VerdictTimeLimit        Verdict = "TLE"
+	VerdictMemoryLimit      Verdict = "MLE"
+	VerdictRuntimeError     Verdict = "RE"
+	VerdictOutputLimit      Verdict = "OLE"
+	VerdictCompilationError Verdict = "CE"
+	VerdictDependencyError  Verdict = "DE"
+	VerdictInternalError    Verdict = "IE"
+	// VerdictSkipped is a case that is not run because of the policy of the verification
+	VerdictSkipped Verdict = "SK"
+
+	// sigXFSZ is sent by the kernel when the file size limit of the sandbox is exceeded
+	sigXFSZ = 25
+	// maxOutputSize is the largest output of the run step that is accepted
+	maxOutputSize = 32 << 20
+)
+
+var (
+	verdictNames = map[Verdict]string{
+		VerdictAccepted:         "Accepted",
+		VerdictWrongAnswer:      "Wrong Answer",
+		VerdictPartial:          "Partially Correct",
+		VerdictTimeLimit:        "Time Limit Exceeded",
+		VerdictMemoryLimit:      "Memory Limit Exceeded",
+		VerdictRuntimeError:     "Runtime Error",
+		VerdictOutputLimit:      "Output Limit Exceeded",
+		VerdictCompilationError: "Compilation Error",
+		VerdictDependencyError:  "Dependency Error",
+		VerdictInternalError:    "Internal Error",
+		VerdictSkipped:          "Skipped",
+	}
+)
+
+func (v Verdict) String() string {
+	if name, ok := verdictNames[v]; ok {
+		return name
+	}
+
+	return string(v)
+}
+
+// caseVerdict derives the verdict of a case from the metadata of the run step and the result of the verify step,
+// the memory limit is only reported by the control groups
+func caseVerdict(res *pipeline.Result) Verdict {
+	meta, ok := res.Metas[RunStepName]
+	if !ok {
+		return VerdictInternalError
+	}
+	switch meta.Status {
+	case "TO":
+		return VerdictTimeLimit
+	case "XX":
+		return VerdictInternalError
+	case "SG":
+		switch {
+		case meta.CgOOMKilled:
+			return VerdictMemoryLimit
+		case meta.ExitSig == sigXFSZ:
+			return VerdictOutputLimit
+		default:
+			return VerdictRuntimeError
+		}
+	case "RE":
+		if meta.CgOOMKilled {
+			return VerdictMemoryLimit
+		}
+
+		return VerdictRuntimeError
+	}
+	if _, ok = res.Errs[RunStepName]; ok {
+		// the step failed without a status from the sandbox
+		return VerdictInternalError
+	}
+	if len(res.Outs[RunStepName]) > maxOutputSize {
+		return VerdictOutputLimit
+	}
+	if _, ok = res.Errs[VerifyStepName]; ok {
+		return VerdictWrongAnswer
+	}
+	if len(res.Errs) > 0 {
+		return VerdictInternalError
+	}
+
+	return VerdictAccepted
+}
+
+// memoryMessage adds the resident memory to the message of a runtime error, without control groups
+// an allocation that crosses the memory limit fails and the program usually crashes
+func memoryMessage(msg string, meta *sandbox.Meta, memoryLimit int) string {
+	if memoryLimit <= 0 || meta.MaxRSS < 0 {
+		return msg
+	}
+	if msg != "" {
+		msg += ", "
+	}
+
+	return msg + fmt.Sprintf("max rss %dKB of the %dKB memory limit", meta.MaxRSS, memoryLimit)
+}
+
+// aggregateVerdict returns the verdict of the first case that is neither accepted nor skipped
+func aggregateVerdict(cases []CaseResult) Verdict {
+	for _, c := range cases {
+		if c.Verdict != VerdictAccepted && c.Verdict != VerdictSkipped {
+			return c.Verdict
+		}
+	}
+
+	return VerdictAccepted
+}
+
+// Summary describes the report in a line, such as "3/10, Wrong Answer on case 4"
+func (r *Report) Summary() string {
+	if len(r.Cases) == 0 {
+		return r.Message
+	}
+	var passNum int
+	for _, c := range r.Cases {
+		if c.Pass {
+			passNum++
+		}
+	}
+	summary := fmt.Sprintf("%d/%d", passNum, len(r.Cases))
+	for _, c := range r.Cases {
+		if c.Verdict != VerdictAccepted && c.Verdict != VerdictSkipped {
+			return fmt.Sprintf("%s, %s on case %s", summary, c.Verdict, c.Name)
+		}
+	}
+
+	return summary
+}
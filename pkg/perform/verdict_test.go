@@ -0,0 +1,99 @@
+package perform
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/vincent-vinf/code-validator/pkg/pipeline"
+	"github.com/vincent-vinf/code-validator/pkg/sandbox"
+)
+
+func TestCaseVerdict(t *testing.T) {
+	tests := []struct {
+		name string
+		meta *sandbox.Meta
+		errs map[string]error
+		want Verdict
+	}{
+		{"accepted", &sandbox.Meta{}, nil, VerdictAccepted},
+		{"wrong answer", &sandbox.Meta{}, map[string]error{VerifyStepName: errors.New("diff")}, VerdictWrongAnswer},
+		{"time limit", &sandbox.Meta{Status: "TO"}, map[string]error{RunStepName: errors.New("timeout")}, VerdictTimeLimit},
+		{"memory limit", &sandbox.Meta{Status: "SG", ExitSig: 9, CgOOMKilled: true}, map[string]error{RunStepName: errors.New("killed")}, VerdictMemoryLimit},
+		{"crash without cgroup", &sandbox.Meta{Status: "SG", ExitSig: 6, MaxRSS: 250000}, map[string]error{RunStepName: errors.New("killed")}, VerdictRuntimeError},
+		{"output limit", &sandbox.Meta{Status: "SG", ExitSig: sigXFSZ}, map[string]error{RunStepName: errors.New("killed")}, VerdictOutputLimit},
+		{"runtime error", &sandbox.Meta{Status: "RE", ExitCode: 1}, map[string]error{RunStepName: errors.New("exit 1")}, VerdictRuntimeError},
+		{"internal error", &sandbox.Meta{Status: "XX"}, map[string]error{RunStepName: errors.New("box")}, VerdictInternalError},
+		{"missing meta", nil, nil, VerdictInternalError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := &pipeline.Result{
+				Metas: map[string]*sandbox.Meta{},
+				Errs:  tt.errs,
+			}
+			if tt.meta != nil {
+				res.Metas[RunStepName] = tt.meta
+			}
+			if got := caseVerdict(res); got != tt.want {
+				t.Errorf("caseVerdict() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryMessage(t *testing.T) {
+	meta := &sandbox.Meta{MaxRSS: 250000}
+	if got, want := memoryMessage("exit status 1", meta, 262144), "exit status 1, max rss 250000KB of the 262144KB memory limit"; got != want {
+		t.Errorf("memoryMessage() = %q, want %q", got, want)
+	}
+	if got := memoryMessage("exit status 1", meta, 0); got != "exit status 1" {
+		t.Errorf("memoryMessage() without limit = %q", got)
+	}
+}
+
+func TestReportSummary(t *testing.T) {
+	rep := &Report{Cases: []CaseResult{
+		{Name: "1", Pass: true, Verdict: VerdictAccepted},
+		{Name: "2", Verdict: VerdictTimeLimit},
+		{Name: "3", Verdict: VerdictWrongAnswer},
+	}}
+	if got, want := rep.Summary(), "1/3, Time Limit Exceeded on case 2"; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+}
+
+func TestJudgeVerdict(t *testing.T) {
+	tests := []struct {
+		name    string
+		meta    *sandbox.Meta
+		score   string
+		want    Verdict
+		partial float64
+	}{
+		{"accepted", &sandbox.Meta{}, "", VerdictAccepted, 1},
+		{"wrong answer", &sandbox.Meta{Status: "RE", ExitCode: JudgeExitWrongAnswer}, "", VerdictWrongAnswer, 0},
+		{"presentation", &sandbox.Meta{Status: "RE", ExitCode: JudgeExitPresentation}, "", VerdictWrongAnswer, 0},
+		{"partial", &sandbox.Meta{Status: "RE", ExitCode: JudgeExitPartial}, "0.25\n", VerdictPartial, 0.25},
+		{"invalid score", &sandbox.Meta{Status: "RE", ExitCode: JudgeExitPartial}, "2", VerdictInternalError, 0},
+		{"nan score", &sandbox.Meta{Status: "RE", ExitCode: JudgeExitPartial}, "nan", VerdictInternalError, 0},
+		{"inf score", &sandbox.Meta{Status: "RE", ExitCode: JudgeExitPartial}, "-Inf", VerdictInternalError, 0},
+		{"judge failed", &sandbox.Meta{Status: "RE", ExitCode: JudgeExitFail}, "", VerdictInternalError, 0},
+		{"judge killed", &sandbox.Meta{Status: "TO"}, "", VerdictInternalError, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := &pipeline.Result{
+				Metas: map[string]*sandbox.Meta{VerifyStepName: tt.meta},
+				Outs:  map[string][]byte{VerifyStepName: []byte("feedback")},
+			}
+			out := map[string][]byte{}
+			if tt.score != "" {
+				out[JudgeScoreFile] = []byte(tt.score)
+			}
+			got, partial, _ := judgeVerdict(res, out)
+			if got != tt.want || partial != tt.partial {
+				t.Errorf("judgeVerdict() = %v %v, want %v %v", got, partial, tt.want, tt.partial)
+			}
+		})
+	}
+}
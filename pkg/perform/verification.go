@@ -5,25 +5,173 @@ import (
 	"fmt"
 	"path"
 
+	"github.com/vincent-vinf/code-validator/pkg/checker"
 	"github.com/vincent-vinf/code-validator/pkg/pipeline"
+	"github.com/vincent-vinf/code-validator/pkg/types"
+	"github.com/vincent-vinf/code-validator/pkg/util/lint"
 )
 
 type Verification struct {
-	Name    string              `json:"name"`
-	Runtime string              `json:"runtime"`
+	Name    string `json:"name"`
+	Runtime string `json:"runtime"`
+	// Version pins the version of the runtime, empty means the default version
+	Version string              `json:"version,omitempty"`
 	Code    *CodeVerification   `json:"code,omitempty"`
 	Custom  *CustomVerification `json:"custom,omitempty"`
+	Suite   *SuiteVerification  `json:"suite,omitempty"`
+	Lint    *LintVerification   `json:"lint,omitempty"`
+}
+
+func (v *Verification) GetRuntime() types.Runtime {
+	return types.Runtime{
+		Lang:    v.Runtime,
+		Version: v.Version,
+	}
 }
 
 type CodeVerification struct {
-	Init   *Action    `json:"init"`
-	Verify string     `json:"verify"`
-	Files  []File     `json:"files"`
-	Cases  []TestCase `json:"cases"`
+	Init    *Action        `json:"init"`
+	Compile *CompileOption `json:"compile,omitempty"`
+	Limit   *Limit         `json:"limit,omitempty"`
+	// Entry is the entry point of a project submission relative to its root,
+	// the default is the source file of the runtime, such as main.py
+	Entry string `json:"entry,omitempty"`
+	// Verify is the shell command of the verify step, it takes precedence over Checker
+	Verify string `json:"verify,omitempty"`
+	// Checker compares ./output with ./answer by a built-in checker when Verify is empty
+	Checker *checker.Options `json:"checker,omitempty"`
+	// Judge is a special judge, it takes precedence over Checker
+	Judge *SpecialJudge `json:"judge,omitempty"`
+	Files []File        `json:"files"`
+	Cases []TestCase    `json:"cases"`
+	// Generator produces Cases, they are replaced by every generation
+	Generator *CaseGenerator `json:"generator,omitempty"`
+	// Validator checks that the input of every case respects the constraints of the problem
+	Validator *Program `json:"validator,omitempty"`
+	// InputCheck is the result of the latest run of the Validator
+	InputCheck *InputCheck `json:"inputCheck,omitempty"`
+	// Groups of the cases, cases without a group are scored by their weight
+	Groups []CaseGroup `json:"groups,omitempty"`
+	// Parallelism is the number of cases that run at the same time, the default is 1
+	Parallelism int `json:"parallelism,omitempty"`
+	// Policy decides which cases are run, the default is PolicyAll
+	Policy string `json:"policy,omitempty"`
+}
+
+const (
+	PolicyAll = "all"
+	// PolicyFailFast skips the remaining cases after the first failed case
+	PolicyFailFast = "fail-fast"
+	// PolicySampleFirst runs the sample cases first, and skips the other cases if any of them fails
+	PolicySampleFirst = "sample-first"
+)
+
+func (c *CodeVerification) Validate() error {
+	if c.Checker != nil {
+		if err := c.Checker.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.useJudge() {
+		if err := c.Judge.validate(); err != nil {
+			return err
+		}
+	}
+	if c.Generator != nil {
+		if err := c.Generator.validate(); err != nil {
+			return err
+		}
+	}
+	for i := range c.Cases {
+		if !validVisibility(c.Cases[i].Visibility) {
+			return fmt.Errorf("unknown visibility %s of case %s", c.Cases[i].Visibility, c.Cases[i].Name)
+		}
+	}
+	switch c.Policy {
+	case "", PolicyAll, PolicyFailFast, PolicySampleFirst:
+	default:
+		return fmt.Errorf("unknown policy %s", c.Policy)
+	}
+	if c.Validator != nil && c.Validator.Source.OssPath == "" {
+		return fmt.Errorf("the source of the input validator cannot be empty")
+	}
+
+	return c.validateGroups()
+}
+
+func (c *CodeVerification) useJudge() bool {
+	return c.Verify == "" && c.Judge != nil
+}
+
+func (c *CodeVerification) verifyCommand() string {
+	if c.Verify != "" {
+		return c.Verify
+	}
+	if c.useJudge() {
+		return c.Judge.command()
+	}
+	opt := c.Checker
+	if opt == nil {
+		opt = &checker.Options{}
+	}
+
+	return opt.Command("./output", "./answer")
+}
+
+// CompileOption is only used by compiled runtimes, the others ignore it
+type CompileOption struct {
+	// Std is the language standard, such as c11 or c++17
+	Std string `json:"std,omitempty"`
+	// Optimize is the optimisation level without the dash, such as O2
+	Optimize string `json:"optimize,omitempty"`
+}
+
+// Limit is the resource limit of the run step of every case, a case can override each of them
+type Limit struct {
+	// Time is the cpu time limit in seconds, it is scaled by the TimeMultiplier of the runtime
+	Time float64 `json:"time,omitempty"`
+	// WallTime in seconds, it is scaled like Time
+	WallTime float64 `json:"wallTime,omitempty"`
+	// Memory in KB
+	Memory int `json:"memory,omitempty"`
+}
+
+// merge returns the limit with the fields set in override replaced
+func (l *Limit) merge(override *Limit) Limit {
+	var res Limit
+	if l != nil {
+		res = *l
+	}
+	if override == nil {
+		return res
+	}
+	if override.Time > 0 {
+		res.Time = override.Time
+	}
+	if override.WallTime > 0 {
+		res.WallTime = override.WallTime
+	}
+	if override.Memory > 0 {
+		res.Memory = override.Memory
+	}
+
+	return res
 }
 
 type CustomVerification struct {
 	Action
+	// ResultFormat is ResultFormatJSON when the action reports by CustomResultFile,
+	// the default is the legacy pass and message files
+	ResultFormat string `json:"resultFormat,omitempty"`
+}
+
+// Validate checks the result format of the custom verification
+func (c *CustomVerification) Validate() error {
+	if c.ResultFormat != "" && c.ResultFormat != ResultFormatJSON {
+		return fmt.Errorf("unknown result format %s", c.ResultFormat)
+	}
+
+	return nil
 }
 
 type Action struct {
@@ -82,7 +230,7 @@ type File struct {
 }
 
 func ReadOSSFile(path string) ([]byte, error) {
-	data, err := ossClient.Get(context.Background(), path)
+	data, err := storage.Get(context.Background(), path)
 	if err != nil {
 		return nil, fmt.Errorf("path %s, err: %w", path, err)
 	}
@@ -91,25 +239,59 @@ func ReadOSSFile(path string) ([]byte, error) {
 }
 
 type Report struct {
-	Pass    bool         `json:"pass"`
-	Message string       `json:"message,omitempty"`
-	Cases   []CaseResult `json:"cases,omitempty"`
+	Pass    bool    `json:"pass"`
+	Verdict Verdict `json:"verdict"`
+	Message string  `json:"message,omitempty"`
+	// MessageVisibility hides a message written by the author from the students, such as the one of a custom action,
+	// the other messages are shown
+	MessageVisibility string       `json:"messageVisibility,omitempty"`
+	Cases             []CaseResult `json:"cases,omitempty"`
+
+	Score    float64       `json:"score"`
+	MaxScore float64       `json:"maxScore"`
+	Groups   []GroupResult `json:"groups,omitempty"`
+
+	// Findings of a lint verification
+	Findings []lint.Finding `json:"findings,omitempty"`
+	// Attachments of a custom verification
+	Attachments []Attachment `json:"attachments,omitempty"`
 }
 
 type TestCase struct {
 	Name string
 	In   File
 	Out  File
+	// Weight of the case, the default is 1
+	Weight float64
+	// Group is the name of the CaseGroup the case belongs to
+	Group string
+	// Limit overrides the limit of the verification
+	Limit *Limit
+	// Visibility is VisibilitySample, VisibilityHidden or VisibilityAfterDeadline, the default is VisibilityHidden
+	Visibility string
 }
 
 type CaseResult struct {
 	Name    string
 	Pass    bool
+	Verdict Verdict
+	// Partial is the fraction of the weight earned by a partially correct case
+	Partial float64
 	Message string
+	// Diff is only computed for the wrong answers of the cases that are not hidden
+	Diff *Diff
+	// Visibility of the case, the details are hidden from the students by it
+	Visibility string
 
 	ExitCode int
 	Time     float64
+	WallTime float64
 	Memory   int
+
+	// the limits that were enforced, zero means the default of the sandbox
+	TimeLimit     float64
+	WallTimeLimit float64
+	MemoryLimit   int
 }
 
 func GetFileName(stepName, path string) string {
@@ -0,0 +1,124 @@
+package perform
+
+import (
+	"encoding/json"
+)
+
+// The visibility of a case decides whether the students can see its input, answer and diff
+const (
+	VisibilitySample = "sample"
+	VisibilityHidden = "hidden"
+	// VisibilityAfterDeadline is hidden until the deadline of the batch
+	VisibilityAfterDeadline = "after-deadline"
+)
+
+// visibility returns the visibility of the case, a case without one is hidden
+func (tc *TestCase) visibility() string {
+	if tc.Visibility != "" {
+		return tc.Visibility
+	}
+
+	return VisibilityHidden
+}
+
+// UnmarshalJSON reads the Visible flag of the cases stored before Visibility, a visible case is a sample
+func (tc *TestCase) UnmarshalJSON(data []byte) error {
+	type testCase TestCase
+	legacy := struct {
+		*testCase
+		Visible bool
+	}{testCase: (*testCase)(tc)}
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return err
+	}
+	if tc.Visibility == "" && legacy.Visible {
+		tc.Visibility = VisibilitySample
+	}
+
+	return nil
+}
+
+func validVisibility(visibility string) bool {
+	switch visibility {
+	case "", VisibilitySample, VisibilityHidden, VisibilityAfterDeadline:
+		return true
+	default:
+		return false
+	}
+}
+
+// revealed reports whether the students can see the details of a case with the visibility
+func revealed(visibility string, afterDeadline bool) bool {
+	switch visibility {
+	case VisibilitySample:
+		return true
+	case VisibilityAfterDeadline:
+		return afterDeadline
+	default:
+		return false
+	}
+}
+
+// ForStudent returns a copy of the verification without the files of the cases that the students cannot see,
+// and without the programs, the commands and the files of the author, such as the special judge,
+// the solution of the generator and the test suite
+func (v *Verification) ForStudent(afterDeadline bool) (*Verification, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	res := &Verification{}
+	if err = json.Unmarshal(data, res); err != nil {
+		return nil, err
+	}
+	if code := res.Code; code != nil {
+		for i := range code.Cases {
+			if !revealed(code.Cases[i].visibility(), afterDeadline) {
+				code.Cases[i].In, code.Cases[i].Out = File{}, File{}
+			}
+		}
+		code.Init = nil
+		code.Verify = ""
+		code.Files = nil
+		code.Judge = nil
+		code.Generator = nil
+		code.Validator = nil
+		code.InputCheck = nil
+	}
+	if suite := res.Suite; suite != nil {
+		suite.Command = ""
+		suite.Files = nil
+		suite.Tests = nil
+	}
+	if res.Custom != nil {
+		res.Custom.Command = ""
+		res.Custom.Files = nil
+	}
+	if res.Lint != nil {
+		res.Lint.Files = nil
+	}
+
+	return res, nil
+}
+
+// ForStudent removes the diffs and the messages of the cases that the students cannot see,
+// the messages of the special judges and the test suites may contain the answers.
+// The message and the attachments written by the author are removed by their visibility too.
+func (r *Report) ForStudent(afterDeadline bool) {
+	if r.MessageVisibility != "" && !revealed(r.MessageVisibility, afterDeadline) {
+		r.Message = ""
+	}
+	attachments := r.Attachments[:0]
+	for _, a := range r.Attachments {
+		if revealed(a.Visibility, afterDeadline) {
+			attachments = append(attachments, a)
+		}
+	}
+	r.Attachments = attachments
+	for i := range r.Cases {
+		if !revealed(r.Cases[i].Visibility, afterDeadline) {
+			r.Cases[i].Diff = nil
+			r.Cases[i].Message = ""
+		}
+	}
+}
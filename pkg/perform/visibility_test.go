@@ -0,0 +1,94 @@
+package perform
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestForStudent(t *testing.T) {
+	vf := &Verification{
+		Name: "v",
+		Code: &CodeVerification{
+			Cases: []TestCase{
+				{Name: "sample", Visibility: VisibilitySample, In: File{OssPath: "1.in"}, Out: File{OssPath: "1.out"}},
+				{Name: "hidden", In: File{OssPath: "2.in"}, Out: File{OssPath: "2.out"}},
+				{Name: "later", Visibility: VisibilityAfterDeadline, In: File{OssPath: "3.in"}, Out: File{OssPath: "3.out"}},
+			},
+			Init:       &Action{Command: "cp ./data.txt ./input", Files: []File{{Path: "./data.txt", OssPath: "data.txt"}}},
+			Verify:     "grep -q 42 ./output",
+			Files:      []File{{Path: "./answer.txt", OssPath: "answer.txt"}},
+			Judge:      &SpecialJudge{Source: File{OssPath: "judge.cpp"}},
+			Generator:  &CaseGenerator{},
+			Validator:  &Program{Source: File{OssPath: "validator.cpp"}},
+			InputCheck: &InputCheck{},
+		},
+		Suite: &SuiteVerification{
+			Command: "pytest --junitxml=./report.xml",
+			Files:   []File{{Path: "./test_main.py", OssPath: "test_main.py"}},
+			Tests:   []TestCase{{Name: "test_main.test_add"}},
+		},
+		Custom: &CustomVerification{Action: Action{Command: "./check.sh", Files: []File{{Path: "./check.sh", OssPath: "check.sh"}}}},
+		Lint:   &LintVerification{Files: []File{{Path: "./.pylintrc", OssPath: "pylintrc"}}},
+	}
+
+	res, err := vf.ForStudent(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := res.Code.Cases
+	if cases[0].Out.OssPath != "1.out" || cases[1].Out.OssPath != "" || cases[2].In.OssPath != "" {
+		t.Errorf("ForStudent(false) cases = %+v", cases)
+	}
+	if res.Code.Judge != nil || vf.Code.Judge == nil || vf.Code.Cases[1].Out.OssPath != "2.out" {
+		t.Error("ForStudent() must only change the copy")
+	}
+	code := res.Code
+	if code.Init != nil || code.Verify != "" || code.Files != nil || code.Generator != nil || code.Validator != nil ||
+		code.InputCheck != nil {
+		t.Errorf("ForStudent() code = %+v", code)
+	}
+	if res.Suite.Command != "" || res.Suite.Files != nil || res.Suite.Tests != nil {
+		t.Errorf("ForStudent() suite = %+v", res.Suite)
+	}
+	if res.Custom.Command != "" || res.Custom.Files != nil || res.Lint.Files != nil {
+		t.Errorf("ForStudent() custom = %+v, lint = %+v", res.Custom, res.Lint)
+	}
+	if res, _ = vf.ForStudent(true); res.Code.Cases[2].Out.OssPath != "3.out" {
+		t.Errorf("ForStudent(true) hides the case revealed after the deadline")
+	}
+
+	rep := &Report{
+		Message:           "the answer is 42",
+		MessageVisibility: VisibilityHidden,
+		Cases: []CaseResult{
+			{Name: "sample", Visibility: VisibilitySample, Diff: &Diff{}, Message: "expected 1, found 2"},
+			{Name: "hidden", Visibility: VisibilityHidden, Diff: &Diff{}, Message: "expected 3, found 4"},
+			{Name: "old", Diff: &Diff{}},
+		},
+		Attachments: []Attachment{{Name: "coverage.html", Visibility: VisibilitySample}, {Name: "answer.txt"}},
+	}
+	rep.ForStudent(false)
+	if rep.Cases[0].Diff == nil || rep.Cases[1].Diff != nil || rep.Cases[2].Diff != nil ||
+		rep.Cases[0].Message == "" || rep.Cases[1].Message != "" {
+		t.Errorf("Report.ForStudent() = %+v", rep.Cases)
+	}
+	if rep.Message != "" || len(rep.Attachments) != 1 || rep.Attachments[0].Name != "coverage.html" {
+		t.Errorf("Report.ForStudent() message = %q, attachments = %+v", rep.Message, rep.Attachments)
+	}
+	rep = &Report{Message: "main.c:1: error"}
+	if rep.ForStudent(false); rep.Message == "" {
+		t.Error("Report.ForStudent() removes the message of the system")
+	}
+}
+
+func TestUnmarshalLegacyVisible(t *testing.T) {
+	var cases []TestCase
+	data := `[{"Name": "1", "Visible": true}, {"Name": "2"}, {"Name": "3", "Visible": true, "Visibility": "hidden"}]`
+	if err := json.Unmarshal([]byte(data), &cases); err != nil {
+		t.Fatal(err)
+	}
+	if cases[0].Visibility != VisibilitySample || cases[1].visibility() != VisibilityHidden ||
+		cases[2].Visibility != VisibilityHidden || cases[0].Name != "1" {
+		t.Errorf("cases = %+v", cases)
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/exec"
 	"path"
-	"time"
+	"strconv"
 
 	"github.com/vincent-vinf/code-validator/pkg/sandbox"
 )
@@ -73,8 +75,10 @@ func (e *Executor) Exec(pipeline Pipeline) (*Result, error) {
 		files[name] = &pipeline.Files[i]
 	}
 	res := &Result{
-		Metas: map[string]*sandbox.Meta{},
-		Errs:  map[string]error{},
+		Metas:   map[string]*sandbox.Meta{},
+		Errs:    map[string]error{},
+		Outs:    map[string][]byte{},
+		Stdouts: map[string][]byte{},
 	}
 	// run
 	for _, step := range pipeline.Steps {
@@ -120,25 +124,23 @@ func (e *Executor) Exec(pipeline Pipeline) (*Result, error) {
 			}
 		}
 
-		network := true
-		var timeout time.Duration
-		if step.Limit != nil {
-			network = step.Limit.EnableNetWork
-			timeout = step.Limit.Time
-		}
-		var combinedOutBuf bytes.Buffer
-		cmdErr := e.box.Run(temp.Cmd, temp.Args,
-			sandbox.Network(network),
+		var combinedOutBuf, stdoutBuf bytes.Buffer
+		opts := []sandbox.Option{
+			sandbox.Network(true),
 			sandbox.Stdin(bytes.NewReader(input)),
-			sandbox.Stdout(&combinedOutBuf),
-			sandbox.Time(timeout),
+			sandbox.Stdout(io.MultiWriter(&combinedOutBuf, &stdoutBuf)),
 			sandbox.Stderr(&combinedOutBuf),
 			sandbox.Metadata(meta),
-			sandbox.Env(map[string]string{
-				"HOME": "/tmp",
-				"PATH": "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
-			}),
-		)
+			sandbox.Dirs(pipeline.Mounts...),
+			sandbox.Env(stepEnv(temp, step.Limit)),
+			sandbox.CPUs(pipeline.CPUs...),
+		}
+		if step.Limit != nil {
+			opts = append(opts, limitOptions(step.Limit)...)
+		}
+		cmdErr := e.box.Run(temp.Cmd, temp.Args, opts...)
+		res.Outs[step.Name] = combinedOutBuf.Bytes()
+		res.Stdouts[step.Name] = stdoutBuf.Bytes()
 		if err := e.writeStepOut(step.Name, combinedOutBuf.Bytes()); err != nil {
 			return res, fmt.Errorf("write step out file, err: %w", err)
 		}
@@ -146,7 +148,11 @@ func (e *Executor) Exec(pipeline Pipeline) (*Result, error) {
 			res.Errs[step.Name] = cmdErr
 
 			if !step.ContinueOnFail {
-				return res, fmt.Errorf("%w, out: %s", cmdErr, combinedOutBuf.String())
+				return res, &StepError{
+					Step: step.Name,
+					Err:  cmdErr,
+					Out:  combinedOutBuf.String(),
+				}
 			}
 		}
 
@@ -158,6 +164,40 @@ func (e *Executor) Exec(pipeline Pipeline) (*Result, error) {
 	return res, nil
 }
 
+// limitOptions converts the limit of a step, the unset limits keep the defaults of the sandbox
+func limitOptions(limit *Limit) []sandbox.Option {
+	opts := []sandbox.Option{sandbox.Network(limit.EnableNetWork)}
+	if limit.Time > 0 {
+		opts = append(opts, sandbox.Time(limit.Time))
+	}
+	if limit.WallTime > 0 {
+		opts = append(opts, sandbox.WallTime(limit.WallTime))
+	}
+	if limit.Memory > 0 {
+		opts = append(opts, sandbox.Memory(limit.Memory))
+		if limit.MemoryEnv != "" {
+			opts = append(opts, sandbox.SelfLimited())
+		}
+	}
+
+	return opts
+}
+
+func stepEnv(temp *Template, limit *Limit) map[string]string {
+	env := map[string]string{
+		"HOME": "/tmp",
+		"PATH": "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+	}
+	for k, v := range temp.Env {
+		env[k] = v
+	}
+	if limit != nil && limit.Memory > 0 && limit.MemoryEnv != "" {
+		env[limit.MemoryEnv] = strconv.Itoa(limit.Memory)
+	}
+
+	return env
+}
+
 func (e *Executor) Clean() error {
 	if err := e.box.Clean(); err != nil {
 		return fmt.Errorf("clean sandbox err: %s", err)
@@ -174,6 +214,16 @@ func (e *Executor) ReadFile(path string) ([]byte, error) {
 	return e.box.ReadFile(path)
 }
 
+// CopyOut copies a file or directory of the box to the host
+func (e *Executor) CopyOut(boxPath, hostPath string) error {
+	src := path.Join(e.box.BoxDir(), path.Clean(boxPath))
+	if out, err := exec.Command("cp", "-a", src, hostPath).CombinedOutput(); err != nil {
+		return fmt.Errorf("copy %s out of the box, err: %w, out: %s", boxPath, err, out)
+	}
+
+	return nil
+}
+
 func (e *Executor) readDataRef(ref DataRef, files map[string]*File) ([]byte, error) {
 	switch {
 	case ref.ExternalRef != nil:
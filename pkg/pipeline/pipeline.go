@@ -1,6 +1,7 @@
 package pipeline
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/vincent-vinf/code-validator/pkg/sandbox"
@@ -10,6 +11,10 @@ type Pipeline struct {
 	Steps     []Step
 	Templates []Template
 	Files     []File
+	// Mounts are host directories visible to every step
+	Mounts []sandbox.Dir
+	// CPUs pins every step to the cpus, empty means any cpu
+	CPUs []int
 }
 
 type Template struct {
@@ -17,6 +22,8 @@ type Template struct {
 
 	Cmd  string
 	Args []string
+	// Env is added to the default environment of the sandbox, and overrides it
+	Env map[string]string
 }
 
 type Step struct {
@@ -34,7 +41,14 @@ type Step struct {
 
 type Limit struct {
 	EnableNetWork bool
-	Time          time.Duration
+	// Time is the cpu time limit, zero keeps the default of the sandbox
+	Time     time.Duration
+	WallTime time.Duration
+	// Memory in KB, zero means unlimited
+	Memory int
+	// MemoryEnv passes Memory to a program that limits its own memory by the variable,
+	// the address space of the program is then not limited without control groups
+	MemoryEnv string
 }
 
 type DataRef struct {
@@ -62,4 +76,23 @@ type File struct {
 type Result struct {
 	Metas map[string]*sandbox.Meta
 	Errs  map[string]error
+	// Outs holds the combined stdout and stderr of every step that was run
+	Outs map[string][]byte
+	// Stdouts holds the stdout of every step that was run, without the stderr
+	Stdouts map[string][]byte
+}
+
+// StepError is returned by Exec when a step that does not continue on failure fails
+type StepError struct {
+	Step string
+	Err  error
+	Out  string
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("%s, out: %s", e.Err, e.Out)
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
 }
@@ -18,9 +18,11 @@ type Meta struct {
 	Time     float64 `json:"time"`
 	TimeWall float64 `json:"timeWall"`
 
-	ExitSig int    `json:"exitSig"`
-	Killed  bool   `json:"killed"`
-	Message string `json:"message"`
+	ExitSig int  `json:"exitSig"`
+	Killed  bool `json:"killed"`
+	// CgOOMKilled is set when the program is killed by the memory limit of the control group
+	CgOOMKilled bool   `json:"cgOOMKilled"`
+	Message     string `json:"message"`
 	//RE: run-time error, i.e., exited with a non-zero exit code
 	//SG: program died on a signal
 	//TO: timed out
@@ -80,6 +82,8 @@ func (m *Meta) ReadFile(path string) error {
 		case "killed":
 			n := atoi(v)
 			m.Killed = n == 1
+		case "cg-oom-killed":
+			m.CgOOMKilled = true
 		case "message":
 			m.Message = v
 		case "status":
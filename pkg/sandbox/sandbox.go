@@ -6,6 +6,7 @@ import (
 	"io"
 	"os/exec"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -16,9 +17,14 @@ const (
 	defaultFileSize = 1024 * 1204 // 1GB
 )
 
+// cgroup makes new boxes use control groups, which is required to limit the memory of all processes of a box
+var cgroup bool
+
 type Sandbox interface {
 	GetID() int
 	Workdir() string
+	// BoxDir is the host path of the working directory inside the sandbox
+	BoxDir() string
 
 	Init() error
 	Run(cmd string, args []string, opts ...Option) error
@@ -39,19 +45,26 @@ func NewIsolate(id int) (*Isolate, error) {
 	}
 	i := &Isolate{
 		id: id,
+		cg: cgroup,
 	}
 
 	return i, nil
 }
 
+// SetCgroup must be called before any box is created, isolate has to be configured with control groups
+func SetCgroup(enable bool) {
+	cgroup = enable
+}
+
 type Isolate struct {
 	id int
+	cg bool
 
 	workdir string
 }
 
 func (i *Isolate) Init() error {
-	if data, err := exec.Command("isolate", fmt.Sprintf("-b %d", i.id), "--init").Output(); err != nil {
+	if data, err := exec.Command("isolate", i.boxArgs("--init")...).Output(); err != nil {
 		return fmt.Errorf("init box(%d) err: %w", i.id, err)
 	} else {
 		i.workdir = strings.TrimSpace(string(data))
@@ -61,7 +74,7 @@ func (i *Isolate) Init() error {
 	return nil
 }
 func (i *Isolate) Clean() error {
-	if err := exec.Command("isolate", fmt.Sprintf("-b %d", i.id), "--cleanup").Run(); err != nil {
+	if err := exec.Command("isolate", i.boxArgs("--cleanup")...).Run(); err != nil {
 		return fmt.Errorf("clean up box(%d) err: %w", i.id, err)
 	}
 
@@ -80,12 +93,20 @@ func (i *Isolate) Run(cmd string, args []string, opts ...Option) error {
 	for _, opt := range opts {
 		opt(r)
 	}
-	gArgs := r.getArgs(i.workdir)
-	gArgs = append(gArgs, fmt.Sprintf("-b %d", i.id), "-s", "--dir=/etc=/etc:noexec", "--run", "--", cmd)
+	gArgs := r.getArgs(i.workdir, i.cg)
+	gArgs = append(gArgs, i.boxArgs("-s", "--dir=/etc=/etc:noexec")...)
+	for _, d := range r.dirs {
+		gArgs = append(gArgs, d.arg())
+	}
+	gArgs = append(gArgs, "--run", "--", cmd)
 	gArgs = append(gArgs, args...)
 	fmt.Println(cmd, " ", strings.Join(args, " "))
 
 	c := exec.Command("isolate", gArgs...)
+	if len(r.cpus) > 0 {
+		// the affinity is inherited by the program in the box
+		c = exec.Command("taskset", append([]string{"--cpu-list", cpuList(r.cpus), "isolate"}, gArgs...)...)
+	}
 	c.Stdin = r.stdin
 	c.Stdout = r.stdout
 	c.Stderr = r.stderr
@@ -101,13 +122,23 @@ func (i *Isolate) Run(cmd string, args []string, opts ...Option) error {
 	return nil
 }
 
+// boxArgs selects the box, the control group flag must be the same for all commands of a box
+func (i *Isolate) boxArgs(args ...string) []string {
+	res := []string{fmt.Sprintf("-b %d", i.id)}
+	if i.cg {
+		res = append(res, "--cg")
+	}
+
+	return append(res, args...)
+}
+
 func (i *Isolate) GetID() int {
 	return i.id
 }
 
 func (i *Isolate) WriteFile(filepath string, data []byte) error {
 	filepath = path.Clean(filepath)
-	stdout, stderr, err := i.runSh(fmt.Sprintf("mkdir -p %s && cat - > %s", path.Dir(filepath), filepath), bytes.NewReader(data))
+	stdout, stderr, err := i.runSh(fmt.Sprintf("mkdir -p %s && cat - > %s", quote(path.Dir(filepath)), quote(filepath)), bytes.NewReader(data))
 
 	if err != nil {
 		return fmt.Errorf("write file err: %w, stdout: %s, stderr: %s", err, stdout, stderr)
@@ -165,10 +196,35 @@ func (i *Isolate) runSh(sh string, stdin io.Reader) (stdout, stderr string, err
 	return outBuf.String(), errBuf.String(), err
 }
 
+// quote makes the string a single word of the shell, since file paths may come from the user
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
 func (i *Isolate) Workdir() string {
 	return i.workdir
 }
 
+func (i *Isolate) BoxDir() string {
+	return path.Join(i.workdir, "box")
+}
+
+// Dir binds a host directory into the sandbox, it is read-only unless ReadWrite is set
+type Dir struct {
+	Inside    string
+	Outside   string
+	ReadWrite bool
+}
+
+func (d Dir) arg() string {
+	arg := fmt.Sprintf("--dir=%s=%s", d.Inside, d.Outside)
+	if d.ReadWrite {
+		arg += ":rw"
+	}
+
+	return arg
+}
+
 type run struct {
 	meta *Meta
 	// --share-net
@@ -180,15 +236,22 @@ type run struct {
 
 	processes int
 	fileSize  int
+	// memoryLimit in KB
+	memoryLimit int
+	// selfLimited is set when the program limits its own memory
+	selfLimited bool
 
-	env map[string]string
+	env  map[string]string
+	dirs []Dir
+	// cpus pins the program to the cpus, empty means any cpu
+	cpus []int
 
 	stdin  io.Reader
 	stdout io.Writer
 	stderr io.Writer
 }
 
-func (r *run) getArgs(workdir string) (args []string) {
+func (r *run) getArgs(workdir string, cg bool) (args []string) {
 	if r.meta != nil {
 		args = append(args, fmt.Sprintf("--meta=%s", path.Join(workdir, "meta")))
 	}
@@ -198,6 +261,14 @@ func (r *run) getArgs(workdir string) (args []string) {
 	if r.fileSize > 0 {
 		args = append(args, fmt.Sprintf("--fsize=%d", r.fileSize))
 	}
+	if r.memoryLimit > 0 {
+		if cg {
+			args = append(args, fmt.Sprintf("--cg-mem=%d", r.memoryLimit))
+		} else if !r.selfLimited {
+			// without control groups only the address space of each process can be limited
+			args = append(args, fmt.Sprintf("--mem=%d", r.memoryLimit))
+		}
+	}
 
 	args = append(args,
 		fmt.Sprintf("--time=%.2f", r.timeLimit.Seconds()),
@@ -248,6 +319,19 @@ func WallTime(t time.Duration) Option {
 		r.wallTimeLimit = t
 	}
 }
+func Memory(kb int) Option {
+	return func(r *run) {
+		r.memoryLimit = kb
+	}
+}
+
+// SelfLimited skips the address space limit without control groups, for the programs that limit their own memory
+// and reserve more address space than they use, such as the JVM
+func SelfLimited() Option {
+	return func(r *run) {
+		r.selfLimited = true
+	}
+}
 func Processes(num int) Option {
 	if num < 0 {
 		num = 0
@@ -269,6 +353,26 @@ func Env(kv map[string]string) Option {
 		r.env = kv
 	}
 }
+func Dirs(dirs ...Dir) Option {
+	return func(r *run) {
+		r.dirs = dirs
+	}
+}
+func CPUs(cpus ...int) Option {
+	return func(r *run) {
+		r.cpus = cpus
+	}
+}
+
+func cpuList(cpus []int) string {
+	list := make([]string, 0, len(cpus))
+	for _, c := range cpus {
+		list = append(list, strconv.Itoa(c))
+	}
+
+	return strings.Join(list, ",")
+}
+
 func Stdin(i io.Reader) Option {
 	return func(r *run) {
 		r.stdin = i
@@ -0,0 +1,278 @@
+package similarity
+
+import (
+	"hash/fnv"
+	"math"
+	"sort"
+)
+
+const (
+	// DefaultK is the number of tokens of a k-gram, a shorter match is never found
+	DefaultK = 12
+	// DefaultWindow is the number of k-grams of a winnowing window, a match of K+Window-1 tokens is always found
+	DefaultWindow = 8
+
+	// maxMatches bounds the matching regions kept for a pair
+	maxMatches = 20
+	// maxPositions bounds the positions of a fingerprint that are paired, a fingerprint repeated more is a pattern
+	maxPositions = 4
+)
+
+// File is a source file of a submission
+type File struct {
+	Name    string
+	Content []byte
+}
+
+// Fingerprint is a k-gram hash selected by winnowing, Pos is the index of the first token of the k-gram
+type Fingerprint struct {
+	Hash uint64
+	Pos  int
+}
+
+// Document is the fingerprinted code of a submission
+type Document struct {
+	ID int
+	// Owner is the user of the submission, the documents of the same owner are not compared
+	Owner        int
+	Tokens       []Token
+	Fingerprints []Fingerprint
+	hashes       map[uint64][]int
+	k, window    int
+}
+
+// NewDocument tokenizes and fingerprints the files, k and window are the parameters of Winnow,
+// the documents that are compared must share them
+func NewDocument(id, owner int, files []File, k, window int) *Document {
+	d := &Document{ID: id, Owner: owner, k: k, window: window}
+	for _, f := range files {
+		d.Tokens = append(d.Tokens, Tokenize(f.Name, f.Content)...)
+	}
+	d.Fingerprints = Winnow(d.Tokens, k, window)
+	d.hashes = make(map[uint64][]int, len(d.Fingerprints))
+	for _, fp := range d.Fingerprints {
+		d.hashes[fp.Hash] = append(d.hashes[fp.Hash], fp.Pos)
+	}
+
+	return d
+}
+
+// Winnow selects the fingerprints of the tokens, the smallest hash of every window of k-grams is selected,
+// and the rightmost one on ties, as described in "Winnowing: Local Algorithms for Document Fingerprinting"
+func Winnow(tokens []Token, k, window int) []Fingerprint {
+	if len(tokens) < k {
+		return nil
+	}
+	tokenHashes := make([]uint64, len(tokens))
+	for i, t := range tokens {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(t.Text))
+		tokenHashes[i] = h.Sum64()
+	}
+	grams := make([]uint64, len(tokens)-k+1)
+	for i := range grams {
+		var h uint64 = 14695981039346656037
+		for _, th := range tokenHashes[i : i+k] {
+			h = (h ^ th) * 1099511628211
+		}
+		grams[i] = h
+	}
+	if window > len(grams) {
+		window = len(grams)
+	}
+
+	var res []Fingerprint
+	last := -1
+	for start := 0; start+window <= len(grams); start++ {
+		smallest := start
+		for i := start; i < start+window; i++ {
+			if grams[i] <= grams[smallest] {
+				smallest = i
+			}
+		}
+		if smallest != last {
+			res = append(res, Fingerprint{Hash: grams[smallest], Pos: smallest})
+			last = smallest
+		}
+	}
+
+	return res
+}
+
+// Region is a range of lines of a file
+type Region struct {
+	File      string `json:"file"`
+	StartLine int    `json:"startLine"`
+	EndLine   int    `json:"endLine"`
+}
+
+// Match is a pair of regions with the same normalised code
+type Match struct {
+	A Region `json:"a"`
+	B Region `json:"b"`
+	// Tokens is the length of the match in the tokens of A
+	Tokens int `json:"tokens"`
+}
+
+// Pair is the similarity between two documents
+type Pair struct {
+	A int `json:"a"`
+	B int `json:"b"`
+	// Similarity is the share of the fingerprints in common, from 0 to 1
+	Similarity float64 `json:"similarity"`
+	Matches    []Match `json:"matches,omitempty"`
+}
+
+type Options struct {
+	// MinSimilarity drops the pairs below it
+	MinSimilarity float64
+	// CommonShare ignores the fingerprints found in more than this share of the documents, such as boilerplate
+	// and the code given by the problem, 0 keeps all of them. A fingerprint is always kept by two documents.
+	CommonShare float64
+}
+
+// Rank compares the documents of different owners, the pairs are sorted from the most similar
+func Rank(docs []*Document, opt Options) []Pair {
+	ignored := commonHashes(docs, opt.CommonShare)
+
+	var res []Pair
+	for i := range docs {
+		for j := i + 1; j < len(docs); j++ {
+			if docs[i].Owner == docs[j].Owner {
+				continue
+			}
+			p := Compare(docs[i], docs[j], ignored)
+			if p.Similarity > 0 && p.Similarity >= opt.MinSimilarity {
+				res = append(res, p)
+			}
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Similarity > res[j].Similarity
+	})
+
+	return res
+}
+
+func commonHashes(docs []*Document, share float64) map[uint64]bool {
+	if share <= 0 {
+		return nil
+	}
+	counts := make(map[uint64]int)
+	for _, d := range docs {
+		for h := range d.hashes {
+			counts[h]++
+		}
+	}
+	limit := share * float64(len(docs))
+	res := make(map[uint64]bool)
+	for h, n := range counts {
+		if n > 2 && float64(n) > limit {
+			res[h] = true
+		}
+	}
+
+	return res
+}
+
+// Compare returns the similarity of two documents, which is the Dice coefficient of their fingerprints,
+// and the regions that match. The ignored fingerprints are left out.
+func Compare(a, b *Document, ignored map[uint64]bool) Pair {
+	p := Pair{A: a.ID, B: b.ID}
+	var sizeA, sizeB, shared int
+	for h := range a.hashes {
+		if ignored[h] {
+			continue
+		}
+		sizeA++
+		if _, ok := b.hashes[h]; ok {
+			shared++
+		}
+	}
+	for h := range b.hashes {
+		if !ignored[h] {
+			sizeB++
+		}
+	}
+	if shared == 0 {
+		return p
+	}
+	p.Similarity = math.Round(2*float64(shared)/float64(sizeA+sizeB)*1000) / 1000
+	p.Matches = matches(a, b, ignored)
+
+	return p
+}
+
+type run struct {
+	startA, endA, startB, endB int
+}
+
+// matches merges the shared fingerprints that follow each other in both documents into regions
+func matches(a, b *Document, ignored map[uint64]bool) []Match {
+	k := a.k
+	var runs []run
+	for _, fp := range a.Fingerprints {
+		if ignored[fp.Hash] {
+			continue
+		}
+		positions := b.hashes[fp.Hash]
+		if len(positions) > maxPositions {
+			continue
+		}
+		for _, posB := range positions {
+			if !extend(runs, a, b, fp.Pos, posB) {
+				runs = append(runs, run{startA: fp.Pos, endA: fp.Pos, startB: posB, endB: posB})
+			}
+		}
+	}
+
+	res := make([]Match, 0, len(runs))
+	for _, r := range runs {
+		res = append(res, Match{
+			A:      region(a.Tokens, r.startA, r.endA+k-1),
+			B:      region(b.Tokens, r.startB, r.endB+k-1),
+			Tokens: r.endA - r.startA + k,
+		})
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Tokens > res[j].Tokens
+	})
+	if len(res) > maxMatches {
+		res = res[:maxMatches]
+	}
+
+	return res
+}
+
+// extend adds the positions to a run that ends right before them in the same files
+func extend(runs []run, a, b *Document, posA, posB int) bool {
+	// the selected fingerprints of a window are at most window k-grams apart
+	window := a.window
+	for i := len(runs) - 1; i >= 0; i-- {
+		r := &runs[i]
+		gapA, gapB := posA-r.endA, posB-r.endB
+		if gapA <= 0 || gapA > window || gapB <= 0 || gapB > window {
+			continue
+		}
+		if a.Tokens[posA].File != a.Tokens[r.startA].File || b.Tokens[posB].File != b.Tokens[r.startB].File {
+			continue
+		}
+		r.endA, r.endB = posA, posB
+
+		return true
+	}
+
+	return false
+}
+
+// region returns the lines of the tokens from start to end, the end is cut at the file of the start
+func region(tokens []Token, start, end int) Region {
+	if end >= len(tokens) {
+		end = len(tokens) - 1
+	}
+	for end > start && tokens[end].File != tokens[start].File {
+		end--
+	}
+
+	return Region{File: tokens[start].File, StartLine: tokens[start].Line, EndLine: tokens[end].Line}
+}
@@ -0,0 +1,99 @@
+package similarity
+
+import (
+	"reflect"
+	"testing"
+)
+
+const original = `import sys
+
+
+def fib(n):
+    # iterative fibonacci
+    a, b = 0, 1
+    for _ in range(n):
+        a, b = b, a + b
+    return a
+
+
+def main():
+    n = int(input())
+    values = [fib(i) for i in range(n)]
+    print(" ".join(str(v) for v in values))
+    print(sum(values), file=sys.stderr)
+
+
+main()
+`
+
+// renamed is the original with other names, comments and constants
+const renamed = `import sys
+
+def fibonacci(count):
+    x, y = 0, 1  # start
+    for _ in range(count):
+        x, y = y, x + y
+    return x
+
+def main():
+    total = int(input())
+    result = [fibonacci(k) for k in range(total)]
+    print(", ".join(str(r) for r in result))
+    print(sum(result), file=sys.stderr)
+
+main()
+`
+
+const unrelated = `def main():
+    words = input().split()
+    counts = {}
+    for w in words:
+        counts[w] = counts.get(w, 0) + 1
+    for w, c in sorted(counts.items()):
+        print(w, c)
+
+if __name__ == "__main__":
+    main()
+`
+
+func TestTokenize(t *testing.T) {
+	got := Tokenize("main.py", []byte("x = 'a' # comment\nif x: print(42)\n"))
+	var texts []string
+	for _, tok := range got {
+		texts = append(texts, tok.Text)
+	}
+	want := []string{"$id", "=", "$str", "if", "$id", ":", "print", "(", "$num", ")"}
+	if !reflect.DeepEqual(texts, want) {
+		t.Errorf("Tokenize() = %q, want %q", texts, want)
+	}
+	if got[3].Line != 2 {
+		t.Errorf("the line of if is %d, want 2", got[3].Line)
+	}
+}
+
+func TestRank(t *testing.T) {
+	doc := func(id, owner int, code string) *Document {
+		return NewDocument(id, owner, []File{{Name: "main.py", Content: []byte(code)}}, DefaultK, DefaultWindow)
+	}
+	docs := []*Document{
+		doc(1, 1, original),
+		doc(2, 2, renamed),
+		doc(3, 3, unrelated),
+		doc(4, 1, original),
+	}
+	pairs := Rank(docs, Options{MinSimilarity: 0.3})
+	if len(pairs) != 2 {
+		t.Fatalf("Rank() = %+v, want the copies of the two owners", pairs)
+	}
+	for _, p := range pairs {
+		if (p.A != 2 && p.B != 2) || p.Similarity < 0.9 {
+			t.Errorf("pair %d-%d has similarity %v", p.A, p.B, p.Similarity)
+		}
+		if len(p.Matches) == 0 {
+			t.Fatalf("pair %d-%d has no matches", p.A, p.B)
+		}
+		if m := p.Matches[0]; m.A.File != "main.py" || m.A.StartLine > 4 || m.A.EndLine < 15 {
+			t.Errorf("the largest match is %+v", m)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package similarity
+
+import (
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The normalised text of the tokens that do not depend on the exact spelling,
+// so that renaming a variable or changing a constant does not hide a copy
+const (
+	identToken  = "$id"
+	numberToken = "$num"
+	stringToken = "$str"
+)
+
+// Token is a normalised token of a source file
+type Token struct {
+	Text string
+	File string
+	Line int
+}
+
+type language struct {
+	lineComments  []string
+	blockComments [][2]string
+	// quotes start string literals, a quote that is not closed on the same line is an operator
+	quotes   string
+	triple   bool
+	keywords map[string]bool
+}
+
+func keywords(words string) map[string]bool {
+	res := make(map[string]bool)
+	for _, w := range strings.Fields(words) {
+		res[w] = true
+	}
+
+	return res
+}
+
+var (
+	cStyle = language{
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        `"'`,
+	}
+
+	languages = map[string]language{
+		"python": {
+			lineComments: []string{"#"},
+			quotes:       `"'`,
+			triple:       true,
+			keywords: keywords(`and as assert async await break class continue def del elif else except
+				finally for from global if import in is lambda nonlocal not or pass raise return try while with yield
+				None True False print input range len int str float list dict set tuple`),
+		},
+		"c": withKeywords(cStyle, `auto break case char const continue default do double else enum extern
+			float for goto if int long register return short signed sizeof static struct switch typedef union
+			unsigned void volatile while include define printf scanf`),
+		"cpp": withKeywords(cStyle, `auto bool break case catch char class const continue default delete do
+			double else enum explicit extern false float for friend goto if inline int long namespace new operator
+			private protected public return short signed sizeof static struct switch template this throw true try
+			typedef typename union unsigned using virtual void volatile while include define std cin cout endl
+			vector string map set`),
+		"java": withKeywords(cStyle, `abstract boolean break byte case catch char class continue default do
+			double else enum extends final finally float for if implements import instanceof int interface long
+			new package private protected public return short static super switch this throw throws try void
+			while true false null String System Scanner`),
+		"javascript": withKeywords(language{
+			lineComments:  cStyle.lineComments,
+			blockComments: cStyle.blockComments,
+			quotes:        "\"'`",
+		}, `async await break case catch class const continue default delete do else export extends false
+			finally for function if import in instanceof let new null return switch this throw true try typeof
+			undefined var void while yield require console`),
+		"go": withKeywords(language{
+			lineComments:  cStyle.lineComments,
+			blockComments: cStyle.blockComments,
+			quotes:        "\"'`",
+		}, `break case chan const continue default defer else fallthrough for func go goto if import
+			interface map package range return select struct switch type var true false nil int string bool
+			byte rune float64 make len append fmt`),
+		"rust": withKeywords(cStyle, `as break const continue else enum false fn for if impl in let loop
+			match mod move mut pub ref return self Self static struct trait true type unsafe use where while
+			i32 i64 u32 u64 usize f64 bool String Vec Option Some None Ok Err println`),
+	}
+
+	// generic is used for the files of an unknown language
+	generic = cStyle
+
+	extensions = map[string]string{
+		".py":   "python",
+		".c":    "c",
+		".h":    "c",
+		".cpp":  "cpp",
+		".cc":   "cpp",
+		".cxx":  "cpp",
+		".hpp":  "cpp",
+		".java": "java",
+		".js":   "javascript",
+		".mjs":  "javascript",
+		".cjs":  "javascript",
+		".ts":   "javascript",
+		".go":   "go",
+		".rs":   "rust",
+	}
+)
+
+func withKeywords(l language, words string) language {
+	l.keywords = keywords(words)
+
+	return l
+}
+
+// IsSource reports whether the file is a source file of a known language
+func IsSource(name string) bool {
+	_, ok := extensions[strings.ToLower(path.Ext(name))]
+
+	return ok
+}
+
+// Tokenize splits a source file into normalised tokens, the language is decided by the extension of the name.
+// Comments and whitespace are dropped, identifiers, numbers and string literals are replaced by placeholders.
+func Tokenize(name string, src []byte) []Token {
+	lang, ok := languages[extensions[strings.ToLower(path.Ext(name))]]
+	if !ok {
+		lang = generic
+	}
+	s := string(src)
+	line := 1
+	var res []Token
+	emit := func(text string) {
+		res = append(res, Token{Text: text, File: name, Line: line})
+	}
+	// skip moves past n bytes, counting the lines
+	skip := func(n int) {
+		line += strings.Count(s[:n], "\n")
+		s = s[n:]
+	}
+
+	for len(s) > 0 {
+		if n := lang.comment(s); n > 0 {
+			skip(n)
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s)
+		switch {
+		case unicode.IsSpace(r):
+			skip(size)
+		case r == '_' || unicode.IsLetter(r):
+			n := scanWord(s, false)
+			if lang.keywords[s[:n]] {
+				emit(s[:n])
+			} else {
+				emit(identToken)
+			}
+			skip(n)
+		case unicode.IsDigit(r):
+			emit(numberToken)
+			skip(scanWord(s, true))
+		default:
+			if n := lang.literal(s); n > 0 {
+				emit(stringToken)
+				skip(n)
+				continue
+			}
+			emit(s[:size])
+			skip(size)
+		}
+	}
+
+	return res
+}
+
+// comment returns the length of the comment at the start of s, or 0
+func (l *language) comment(s string) int {
+	for _, c := range l.lineComments {
+		if strings.HasPrefix(s, c) {
+			if i := strings.IndexByte(s, '\n'); i >= 0 {
+				return i
+			}
+			return len(s)
+		}
+	}
+	for _, c := range l.blockComments {
+		if strings.HasPrefix(s, c[0]) {
+			if i := strings.Index(s[len(c[0]):], c[1]); i >= 0 {
+				return len(c[0]) + i + len(c[1])
+			}
+			return len(s)
+		}
+	}
+
+	return 0
+}
+
+// literal returns the length of the string literal at the start of s, or 0
+func (l *language) literal(s string) int {
+	q := s[0]
+	if strings.IndexByte(l.quotes, q) < 0 {
+		return 0
+	}
+	if l.triple && len(s) >= 3 && s[1] == q && s[2] == q {
+		if i := strings.Index(s[3:], s[:3]); i >= 0 {
+			return 3 + i + 3
+		}
+		return len(s)
+	}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case q:
+			return i + 1
+		case '\n':
+			// a template literal spans lines, the other quotes are closed on the same line
+			if q != '`' {
+				return 0
+			}
+		}
+	}
+
+	return 0
+}
+
+// scanWord returns the length of the identifier or the number at the start of s
+func scanWord(s string, number bool) int {
+	for i, r := range s {
+		if r != '_' && !(number && r == '.') && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return i
+		}
+	}
+
+	return len(s)
+}
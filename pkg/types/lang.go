@@ -1,14 +1,43 @@
 package types
 
+import (
+	"fmt"
+	"time"
+)
+
 const (
 	JavaScriptRuntime = "Javascript"
 	PythonRuntime     = "Python"
 	GolangRuntime     = "Golang"
 	CPPRuntime        = "CPP"
 	CRuntime          = "C"
+	JavaRuntime       = "Java"
+	RustRuntime       = "Rust"
+)
+
+const (
+	// RuntimeAdvertiseInterval is how often actuators advertise the runtimes they serve
+	RuntimeAdvertiseInterval = time.Minute
 )
 
 type Runtime struct {
 	Lang    string `json:"lang"`
 	Version string `json:"version"`
 }
+
+// RouteKey is the routing key of the queue of the runtime, an empty version is the default version of the language
+func (r Runtime) RouteKey() string {
+	if r.Version == "" {
+		return r.Lang
+	}
+
+	return fmt.Sprintf("%s-%s", r.Lang, r.Version)
+}
+
+func (r Runtime) String() string {
+	if r.Version == "" {
+		return r.Lang
+	}
+
+	return fmt.Sprintf("%s %s", r.Lang, r.Version)
+}
@@ -5,9 +5,36 @@ const (
 	TaskStatusFinish  = "finish"
 	TaskStatusSuccess = "success"
 	TaskStatusFailed  = "failed"
+	// TaskStatusDependencyFailed means the dependencies of the code could not be installed
+	TaskStatusDependencyFailed = "dependency-failed"
+)
+
+const (
+	TaskKindSubmission = "submission"
+	// TaskKindReference is the reference solution of the author, which validates a batch
+	TaskKindReference = "reference"
+)
+
+const (
+	// BatchStatusUnvalidated is a batch without a reference solution
+	BatchStatusUnvalidated = "unvalidated"
+	BatchStatusValidating  = "validating"
+	// BatchStatusValidated means the reference solution passes every case
+	BatchStatusValidated = "validated"
+	BatchStatusInvalid   = "invalid"
+)
+
+const (
+	// RequestKindGenerate generates the cases of the verification,
+	// and then runs the subtask of the task if TaskID is set
+	RequestKindGenerate = "generate"
+	// RequestKindCheckInputs runs the input validator of the verification on its cases
+	RequestKindCheckInputs = "check-inputs"
 )
 
 type SubTaskRequest struct {
 	TaskID         int
 	VerificationID int
+	// Kind is empty for a subtask
+	Kind string `json:",omitempty"`
 }
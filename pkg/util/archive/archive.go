@@ -0,0 +1,230 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+const (
+	FormatZip   = "zip"
+	FormatTar   = "tar"
+	FormatTarGz = "tar.gz"
+
+	MaxFiles = 1000
+	// MaxSize is the max total size of the extracted files
+	MaxSize = 64 << 20
+)
+
+// Entry is a regular file of an archive
+type Entry struct {
+	Path    string
+	Content []byte
+}
+
+// DetectFormat returns the archive format by the file name
+func DetectFormat(name string) (string, error) {
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return FormatZip, nil
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return FormatTarGz, nil
+	case strings.HasSuffix(name, ".tar"):
+		return FormatTar, nil
+	default:
+		return "", fmt.Errorf("unsupported archive: %s", name)
+	}
+}
+
+// CheckPath returns the cleaned path, it rejects paths that would escape the extraction directory
+func CheckPath(p string) (string, error) {
+	if p == "" || path.IsAbs(p) || strings.Contains(p, "\\") {
+		return "", fmt.Errorf("invalid file path: %q", p)
+	}
+	for _, r := range p {
+		if r < 0x20 || r == 0x7f {
+			return "", fmt.Errorf("invalid file path: %q", p)
+		}
+	}
+	p = path.Clean(p)
+	if p == "." || p == ".." || strings.HasPrefix(p, "../") {
+		return "", fmt.Errorf("invalid file path: %q", p)
+	}
+
+	return p, nil
+}
+
+// Extract reads the regular files of the archive, directories are skipped and links are rejected.
+// If all files are in the same top-level directory, the directory is removed from their paths.
+func Extract(data []byte, format string) ([]Entry, error) {
+	var (
+		entries []Entry
+		err     error
+	)
+	switch format {
+	case FormatZip:
+		entries, err = extractZip(data)
+	case FormatTar:
+		entries, err = extractTar(bytes.NewReader(data))
+	case FormatTarGz:
+		var r *gzip.Reader
+		r, err = gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		entries, err = extractTar(r)
+	default:
+		return nil, fmt.Errorf("unsupported archive format: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("the archive is empty")
+	}
+
+	return trimRootDir(entries), nil
+}
+
+func extractZip(data []byte) ([]Entry, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	l := &limiter{}
+	var res []Entry
+	for _, f := range reader.File {
+		mode := f.FileInfo().Mode()
+		if mode.IsDir() {
+			continue
+		}
+		if !mode.IsRegular() {
+			return nil, fmt.Errorf("unsupported file type: %s", f.Name)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		e, err := l.read(f.Name, rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, e)
+	}
+
+	return res, nil
+}
+
+func extractTar(r io.Reader) ([]Entry, error) {
+	reader := tar.NewReader(r)
+	l := &limiter{}
+	var res []Entry
+	for {
+		h, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch h.Typeflag {
+		case tar.TypeDir, tar.TypeXGlobalHeader:
+			continue
+		case tar.TypeReg:
+		default:
+			return nil, fmt.Errorf("unsupported file type: %s", h.Name)
+		}
+		e, err := l.read(h.Name, reader)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, e)
+	}
+
+	return res, nil
+}
+
+type limiter struct {
+	files int
+	size  int64
+	// paths are the cleaned paths that have been read, such as a/b.py and ./a/b.py are the same file
+	paths map[string]bool
+}
+
+func (l *limiter) read(name string, r io.Reader) (Entry, error) {
+	p, err := CheckPath(name)
+	if err != nil {
+		return Entry{}, err
+	}
+	if l.paths[p] {
+		return Entry{}, fmt.Errorf("duplicate file in the archive: %s", p)
+	}
+	if l.paths == nil {
+		l.paths = make(map[string]bool)
+	}
+	l.paths[p] = true
+	l.files++
+	if l.files > MaxFiles {
+		return Entry{}, fmt.Errorf("too many files, the limit is %d", MaxFiles)
+	}
+	// read one more byte to find out whether the limit is exceeded
+	data, err := io.ReadAll(io.LimitReader(r, MaxSize-l.size+1))
+	if err != nil {
+		return Entry{}, err
+	}
+	l.size += int64(len(data))
+	if l.size > MaxSize {
+		return Entry{}, fmt.Errorf("the archive is too large, the limit is %d bytes", MaxSize)
+	}
+
+	return Entry{Path: p, Content: data}, nil
+}
+
+func trimRootDir(entries []Entry) []Entry {
+	root, _, ok := strings.Cut(entries[0].Path, "/")
+	if !ok {
+		return entries
+	}
+	for _, e := range entries[1:] {
+		if !strings.HasPrefix(e.Path, root+"/") {
+			return entries
+		}
+	}
+	for i := range entries {
+		entries[i].Path = strings.TrimPrefix(entries[i].Path, root+"/")
+	}
+
+	return entries
+}
+
+// Zip packs the files into a zip archive
+func Zip(files map[string]string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		p, err := CheckPath(name)
+		if err != nil {
+			return nil, err
+		}
+		f, err := w.Create(p)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = f.Write([]byte(content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
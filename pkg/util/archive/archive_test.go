@@ -0,0 +1,79 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"testing"
+)
+
+func TestExtractZip(t *testing.T) {
+	data, err := Zip(map[string]string{
+		"project/main.py":     "import util",
+		"project/lib/util.py": "x = 1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := Extract(data, FormatZip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, e := range entries {
+		got[e.Path] = string(e.Content)
+	}
+	if got["main.py"] != "import util" || got["lib/util.py"] != "x = 1" {
+		t.Fatalf("unexpected entries: %v", got)
+	}
+}
+
+func TestExtractTarRejectsUnsafeEntries(t *testing.T) {
+	for _, h := range []*tar.Header{
+		{Name: "../evil", Typeflag: tar.TypeReg},
+		{Name: "/etc/passwd", Typeflag: tar.TypeReg},
+		{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"},
+	} {
+		var buf bytes.Buffer
+		w := tar.NewWriter(&buf)
+		if err := w.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Extract(buf.Bytes(), FormatTar); err == nil {
+			t.Errorf("entry %s should be rejected", h.Name)
+		}
+	}
+}
+
+func TestExtractTarRejectsDuplicates(t *testing.T) {
+	var buf bytes.Buffer
+	w := tar.NewWriter(&buf)
+	for _, name := range []string{"main.py", "./main.py"} {
+		if err := w.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Extract(buf.Bytes(), FormatTar); err == nil {
+		t.Error("duplicate entries should be rejected")
+	}
+}
+
+func TestCheckPath(t *testing.T) {
+	for p, ok := range map[string]bool{
+		"main.py":      true,
+		"./a/../b.py":  true,
+		"a/../../b.py": false,
+		"..":           false,
+		"a\\b":         false,
+		"":             false,
+	} {
+		if _, err := CheckPath(p); (err == nil) != ok {
+			t.Errorf("CheckPath(%q) err: %v", p, err)
+		}
+	}
+}
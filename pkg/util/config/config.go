@@ -11,7 +11,12 @@ type Config struct {
 	JWT      JWT      `yaml:"jwt"`
 	RabbitMQ RabbitMQ `yaml:"rabbitmq"`
 	Minio    Minio    `yaml:"minio"`
+	Storage  Storage  `yaml:"storage"`
 	Mysql    Mysql    `yaml:"mysql"`
+	// Runtimes are registered in addition to the built-in runtimes of the actuator
+	Runtimes   []Runtime  `yaml:"runtimes"`
+	Dependency Dependency `yaml:"dependency"`
+	Sandbox    Sandbox    `yaml:"sandbox"`
 }
 
 type Mysql struct {
@@ -35,6 +40,12 @@ type JWT struct {
 	MaxRefresh time.Duration `yaml:"maxRefresh"`
 }
 
+// Storage selects the backend of the files, MinIO is used without LocalDir
+type Storage struct {
+	// LocalDir stores the files in a local directory, for offline development and testing
+	LocalDir string `yaml:"localDir"`
+}
+
 type Minio struct {
 	Endpoint        string `yaml:"endpoint"`
 	AccessKeyID     string `yaml:"accessKeyID"`
@@ -42,6 +53,42 @@ type Minio struct {
 	Bucket          string `yaml:"bucket"`
 }
 
+type Runtime struct {
+	Lang    string    `yaml:"lang"`
+	Version string    `yaml:"version"`
+	Source  string    `yaml:"source"`
+	Compile *Template `yaml:"compile"`
+	// Artifacts are the paths written by Compile that Run needs, the code is then compiled once for all cases
+	Artifacts []string `yaml:"artifacts"`
+	Run       Template `yaml:"run"`
+	// MemoryEnv passes the memory limit in KB to Run, which limits its own memory instead of the address space
+	MemoryEnv      string   `yaml:"memoryEnv"`
+	TimeMultiplier float64  `yaml:"timeMultiplier"`
+	Requires       []string `yaml:"requires"`
+}
+
+// Dependency enables installing the dependencies of projects without network
+type Dependency struct {
+	// EnvCacheDir keeps the installed environments by the hash of their lockfile
+	EnvCacheDir string `yaml:"envCacheDir"`
+	// PackageDirs maps a runtime lang to its allowlisted local package cache,
+	// such as a wheel directory for Python or an npm cache for Javascript
+	PackageDirs map[string]string `yaml:"packageDirs"`
+}
+
+type Sandbox struct {
+	// Cgroup runs the boxes with control groups, which limits the memory of all processes of a program
+	Cgroup bool `yaml:"cgroup"`
+	// CPUs are the cpus that the cases are pinned to, one case runs on each of them at a time
+	CPUs []int `yaml:"cpus"`
+}
+
+type Template struct {
+	Cmd  string            `yaml:"cmd"`
+	Args []string          `yaml:"args"`
+	Env  map[string]string `yaml:"env"`
+}
+
 func Load(path string) (Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
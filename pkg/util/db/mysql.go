@@ -112,7 +112,7 @@ func IsExistEmail(email string) (bool, error) {
 
 func ListBatchWithUserName() ([]vo.Batch, error) {
 	db := getInstance()
-	rows, err := db.Query("SELECT b.id,u.id,u.username,b.name,b.runtime,b.info,b.create_at FROM batch b LEFT JOIN user u ON b.user_id = u.id")
+	rows, err := db.Query("SELECT b.id,u.id,u.username,b.name,b.runtime,b.version,b.info,b.status,b.reference_task_id,b.deadline,b.create_at FROM batch b LEFT JOIN user u ON b.user_id = u.id")
 	if err != nil {
 		return nil, err
 	}
@@ -120,7 +120,7 @@ func ListBatchWithUserName() ([]vo.Batch, error) {
 	defer rows.Close()
 	for rows.Next() {
 		v := vo.Batch{}
-		if err = rows.Scan(&v.ID, &v.UserID, &v.Username, &v.Name, &v.Runtime, &v.Describe, &v.CreatedAt); err != nil {
+		if err = rows.Scan(&v.ID, &v.UserID, &v.Username, &v.Name, &v.Runtime, &v.Version, &v.Describe, &v.Status, &v.ReferenceTaskID, &v.Deadline, &v.CreatedAt); err != nil {
 			return nil, err
 		}
 		res = append(res, v)
@@ -136,7 +136,7 @@ func GetBatchByIDWithVerifications(id int) (*orm.Batch, error) {
 		return nil, err
 	}
 	db := getInstance()
-	rows, err := db.Query("select id,name,runtime,data from verification where batch_id = ?", v.ID)
+	rows, err := db.Query("select id,name,runtime,version,data from verification where batch_id = ?", v.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -144,7 +144,7 @@ func GetBatchByIDWithVerifications(id int) (*orm.Batch, error) {
 		vf := &orm.Verification{
 			BatchID: v.ID,
 		}
-		if err = rows.Scan(&vf.ID, &vf.Name, &vf.Runtime, &vf.Data); err != nil {
+		if err = rows.Scan(&vf.ID, &vf.Name, &vf.Runtime, &vf.Version, &vf.Data); err != nil {
 			return nil, err
 		}
 		v.Verifications = append(v.Verifications, vf)
@@ -155,7 +155,7 @@ func GetBatchByIDWithVerifications(id int) (*orm.Batch, error) {
 
 func GetBatchByID(id int) (*orm.Batch, error) {
 	db := getInstance()
-	rows, err := db.Query("select user_id,name,runtime,info,create_at from batch where id = ?", id)
+	rows, err := db.Query("select user_id,name,runtime,version,info,status,reference_task_id,deadline,create_at from batch where id = ?", id)
 	if err != nil {
 		return nil, err
 	}
@@ -164,7 +164,7 @@ func GetBatchByID(id int) (*orm.Batch, error) {
 		ID: id,
 	}
 	if rows.Next() {
-		if err = rows.Scan(&v.UserID, &v.Name, &v.Runtime, &v.Describe, &v.CreatedAt); err != nil {
+		if err = rows.Scan(&v.UserID, &v.Name, &v.Runtime, &v.Version, &v.Describe, &v.Status, &v.ReferenceTaskID, &v.Deadline, &v.CreatedAt); err != nil {
 			return nil, err
 		}
 	} else {
@@ -188,7 +188,7 @@ func AddBatch(batch *orm.Batch) (err error) {
 			err = tx.Commit()
 		}
 	}()
-	r, err := tx.Exec("insert into batch(user_id, name, runtime, info, create_at) values (?,?,?,?,?)", batch.UserID, batch.Name, batch.Runtime, batch.Describe, batch.CreatedAt)
+	r, err := tx.Exec("insert into batch(user_id, name, runtime, version, info, status, deadline, create_at) values (?,?,?,?,?,?,?,?)", batch.UserID, batch.Name, batch.Runtime, batch.Version, batch.Describe, batch.Status, batch.Deadline, batch.CreatedAt)
 	if err != nil {
 		return
 	}
@@ -197,23 +197,41 @@ func AddBatch(batch *orm.Batch) (err error) {
 		return
 	}
 	batch.ID = int(id)
-	stmt, err := tx.Prepare("insert into verification(batch_id, name, runtime, data) values (?,?,?,?)")
+	stmt, err := tx.Prepare("insert into verification(batch_id, name, runtime, version, data) values (?,?,?,?,?)")
 	if err != nil {
 		return
 	}
 	for _, v := range batch.Verifications {
-		_, err = stmt.Exec(id, v.Name, v.Runtime, v.Data)
+		r, err = stmt.Exec(id, v.Name, v.Runtime, v.Version, v.Data)
 		if err != nil {
 			return
 		}
+		var vid int64
+		if vid, err = r.LastInsertId(); err != nil {
+			return
+		}
+		v.ID, v.BatchID = int(vid), batch.ID
 	}
 
 	return
 }
 
+// UpdateVerificationData replaces the definition of the verification, such as the generated cases
+func UpdateVerificationData(id int, data string) (err error) {
+	db := getInstance()
+	stmt, err := db.Prepare("UPDATE verification SET data = ? WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(data, id)
+
+	return
+}
+
 func GetVerificationByID(id int) (*orm.Verification, error) {
 	db := getInstance()
-	rows, err := db.Query("select batch_id,name,runtime,data from verification where id = ?", id)
+	rows, err := db.Query("select batch_id,name,runtime,version,data from verification where id = ?", id)
 	if err != nil {
 		return nil, err
 	}
@@ -223,7 +241,7 @@ func GetVerificationByID(id int) (*orm.Verification, error) {
 		v := &orm.Verification{
 			ID: id,
 		}
-		if err = rows.Scan(&v.BatchID, &v.Name, &v.Runtime, &v.Data); err != nil {
+		if err = rows.Scan(&v.BatchID, &v.Name, &v.Runtime, &v.Version, &v.Data); err != nil {
 			return nil, err
 		}
 
@@ -235,7 +253,7 @@ func GetVerificationByID(id int) (*orm.Verification, error) {
 
 func GetTaskByID(id int) (*orm.Task, error) {
 	db := getInstance()
-	rows, err := db.Query("select user_id,batch_id,create_at from task where id = ?", id)
+	rows, err := db.Query("select user_id,batch_id,kind,code_type,create_at from task where id = ?", id)
 	if err != nil {
 		return nil, err
 	}
@@ -244,7 +262,7 @@ func GetTaskByID(id int) (*orm.Task, error) {
 		ID: id,
 	}
 	if rows.Next() {
-		if err = rows.Scan(&task.UserID, &task.BatchID, &task.CreatedAt); err != nil {
+		if err = rows.Scan(&task.UserID, &task.BatchID, &task.Kind, &task.CodeType, &task.CreatedAt); err != nil {
 			return nil, err
 		}
 	} else {
@@ -257,7 +275,7 @@ func GetTaskByID(id int) (*orm.Task, error) {
 // GetTaskInfoByID with subtask
 func GetTaskInfoByID(id int) (*vo.Task, error) {
 	db := getInstance()
-	rows, err := db.Query("SELECT u.id,u.username,t.batch_id,b.`name`,b.runtime,t.create_at FROM task t LEFT JOIN user u ON t.user_id = u.id LEFT JOIN batch b ON t.batch_id = b.id where t.id = ?", id)
+	rows, err := db.Query("SELECT u.id,u.username,t.batch_id,b.`name`,b.runtime,t.score,t.max_score,t.create_at FROM task t LEFT JOIN user u ON t.user_id = u.id LEFT JOIN batch b ON t.batch_id = b.id where t.id = ?", id)
 	if err != nil {
 		return nil, err
 	}
@@ -265,22 +283,26 @@ func GetTaskInfoByID(id int) (*vo.Task, error) {
 	task := &vo.Task{}
 	task.ID = id
 	if rows.Next() {
-		if err = rows.Scan(&task.UserID, &task.Username, &task.BatchID, &task.BatchName, &task.Runtime, &task.CreatedAt); err != nil {
+		if err = rows.Scan(&task.UserID, &task.Username, &task.BatchID, &task.BatchName, &task.Runtime, &task.Score, &task.MaxScore, &task.CreatedAt); err != nil {
 			return nil, err
 		}
 	} else {
 		return nil, fmt.Errorf("the task with id %d does not exist", id)
 	}
-	rows, err = db.Query("select subtask.id,verification_id,v.`name`,status,result,message from subtask LEFT JOIN verification v ON v.id = verification_id where task_id = ?", id)
+	rows, err = db.Query("select subtask.id,verification_id,v.`name`,status,result,verdict,message,score,max_score,report from subtask LEFT JOIN verification v ON v.id = verification_id where task_id = ?", id)
 	if err != nil {
 		return nil, err
 	}
 	for rows.Next() {
 		s := &vo.SubTask{}
 		s.TaskID = task.ID
-		if err = rows.Scan(&s.ID, &s.VerificationID, &s.VerificationName, &s.Status, &s.Result, &s.Message); err != nil {
+		var report []byte
+		if err = rows.Scan(&s.ID, &s.VerificationID, &s.VerificationName, &s.Status, &s.Result, &s.Verdict, &s.Message, &s.Score, &s.MaxScore, &report); err != nil {
 			return nil, err
 		}
+		if len(report) > 0 {
+			s.Report = report
+		}
 		task.SubTasks = append(task.SubTasks, s)
 	}
 
@@ -294,9 +316,9 @@ func ListTasks(batchID, userID int) ([]vo.Task, error) {
 		err  error
 	)
 	if batchID != 0 {
-		rows, err = db.Query("SELECT t.id,u.id,u.username,t.batch_id,b.`name`,b.runtime,t.create_at FROM task t LEFT JOIN user u ON t.user_id = u.id LEFT JOIN batch b ON t.batch_id = b.id where t.batch_id = ?", batchID)
+		rows, err = db.Query("SELECT t.id,u.id,u.username,t.batch_id,b.`name`,b.runtime,t.code_type,t.score,t.max_score,t.create_at FROM task t LEFT JOIN user u ON t.user_id = u.id LEFT JOIN batch b ON t.batch_id = b.id where t.batch_id = ? and t.kind = ?", batchID, types.TaskKindSubmission)
 	} else {
-		rows, err = db.Query("SELECT t.id,u.id,u.username,t.batch_id,b.`name`,b.runtime,t.create_at FROM task t LEFT JOIN user u ON t.user_id = u.id LEFT JOIN batch b ON t.batch_id = b.id where t.user_id = ?", userID)
+		rows, err = db.Query("SELECT t.id,u.id,u.username,t.batch_id,b.`name`,b.runtime,t.code_type,t.score,t.max_score,t.create_at FROM task t LEFT JOIN user u ON t.user_id = u.id LEFT JOIN batch b ON t.batch_id = b.id where t.user_id = ? and t.kind = ?", userID, types.TaskKindSubmission)
 	}
 	if err != nil {
 		return nil, err
@@ -305,7 +327,7 @@ func ListTasks(batchID, userID int) ([]vo.Task, error) {
 	defer rows.Close()
 	for rows.Next() {
 		v := vo.Task{}
-		if err = rows.Scan(&v.ID, &v.UserID, &v.Username, &v.BatchID, &v.BatchName, &v.Runtime, &v.CreatedAt); err != nil {
+		if err = rows.Scan(&v.ID, &v.UserID, &v.Username, &v.BatchID, &v.BatchName, &v.Runtime, &v.CodeType, &v.Score, &v.MaxScore, &v.CreatedAt); err != nil {
 			return nil, err
 		}
 		res = append(res, v)
@@ -316,12 +338,12 @@ func ListTasks(batchID, userID int) ([]vo.Task, error) {
 
 func AddTask(task *orm.Task) (err error) {
 	db := getInstance()
-	stmt, err := db.Prepare("insert into task (user_id , batch_id, create_at) VALUES (?,?,?)")
+	stmt, err := db.Prepare("insert into task (user_id , batch_id, kind, code_type, create_at) VALUES (?,?,?,?,?)")
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
-	res, err := stmt.Exec(task.UserID, task.BatchID, task.CreatedAt)
+	res, err := stmt.Exec(task.UserID, task.BatchID, task.Kind, task.CodeType, task.CreatedAt)
 	if err != nil {
 		return err
 	}
@@ -336,12 +358,12 @@ func AddTask(task *orm.Task) (err error) {
 
 func AddSubTask(subtask *orm.SubTask) (err error) {
 	db := getInstance()
-	stmt, err := db.Prepare("insert into subtask (task_id , verification_id, status, result, message) VALUES (?,?,?,?,?)")
+	stmt, err := db.Prepare("insert into subtask (task_id , verification_id, status, result, verdict, message, score, max_score) VALUES (?,?,?,?,?,?,?,?)")
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
-	res, err := stmt.Exec(subtask.TaskID, subtask.VerificationID, subtask.Status, subtask.Result, subtask.Message)
+	res, err := stmt.Exec(subtask.TaskID, subtask.VerificationID, subtask.Status, subtask.Result, subtask.Verdict, subtask.Message, subtask.Score, subtask.MaxScore)
 	if err != nil {
 		return err
 	}
@@ -356,15 +378,71 @@ func AddSubTask(subtask *orm.SubTask) (err error) {
 
 func UpdateSubTask(subtask *orm.SubTask) (err error) {
 	db := getInstance()
-	stmt, err := db.Prepare("UPDATE subtask SET status = ?, result = ?, message = ?  WHERE id = ?")
+	stmt, err := db.Prepare("UPDATE subtask SET status = ?, result = ?, verdict = ?, message = ?, score = ?, max_score = ?, report = ?  WHERE id = ?")
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
-	_, err = stmt.Exec(subtask.Status, subtask.Result, subtask.Message, subtask.ID)
+	_, err = stmt.Exec(subtask.Status, subtask.Result, subtask.Verdict, subtask.Message, subtask.Score, subtask.MaxScore, nullJSON(subtask.Report), subtask.ID)
+	if err != nil {
+		return err
+	}
+
+	return
+}
+
+// SetBatchReference starts the validation of a batch by the reference task
+func SetBatchReference(batchID, taskID int) (err error) {
+	db := getInstance()
+	stmt, err := db.Prepare("UPDATE batch SET reference_task_id = ?, status = ? WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(taskID, types.BatchStatusValidating, batchID)
+
+	return
+}
+
+// UpdateReferenceStatus marks the batch validated when every verification of the reference task succeeds,
+// it is called after each subtask finishes and does nothing for an outdated reference task
+func UpdateReferenceStatus(taskID int) error {
+	db := getInstance()
+	var batchID, total, finished, succeeded int
+	err := db.QueryRow("SELECT t.batch_id, (SELECT COUNT(*) FROM verification v WHERE v.batch_id = t.batch_id) FROM task t WHERE t.id = ?", taskID).
+		Scan(&batchID, &total)
+	if err != nil {
+		return err
+	}
+	err = db.QueryRow("SELECT COUNT(*), COALESCE(SUM(result = ?), 0) FROM subtask WHERE task_id = ? AND status = ?",
+		types.TaskStatusSuccess, taskID, types.TaskStatusFinish).Scan(&finished, &succeeded)
+	if err != nil {
+		return err
+	}
+
+	var status string
+	switch {
+	case finished < total:
+		return nil
+	case succeeded == total:
+		status = types.BatchStatusValidated
+	default:
+		status = types.BatchStatusInvalid
+	}
+	_, err = db.Exec("UPDATE batch SET status = ? WHERE id = ? AND reference_task_id = ?", status, batchID, taskID)
+
+	return err
+}
+
+// UpdateTaskScore sums the scores of the subtasks into the task
+func UpdateTaskScore(taskID int) (err error) {
+	db := getInstance()
+	stmt, err := db.Prepare("UPDATE task SET score = (SELECT COALESCE(SUM(score), 0) FROM subtask WHERE task_id = ?), max_score = (SELECT COALESCE(SUM(max_score), 0) FROM subtask WHERE task_id = ?) WHERE id = ?")
 	if err != nil {
 		return err
 	}
+	defer stmt.Close()
+	_, err = stmt.Exec(taskID, taskID, taskID)
 
 	return
 }
@@ -414,12 +492,10 @@ order by a.some_day
 	return res, nil
 }
 
-func Runtime7dayCount() ([]vo.RuntimeDayCnt, error) {
+// Runtime7dayCount counts the tasks of the last 7 days of every language
+func Runtime7dayCount(langs []string) ([]vo.RuntimeDayCnt, error) {
 	res := make([]vo.RuntimeDayCnt, 0)
-	for _, runtime := range []string{
-		types.JavaScriptRuntime,
-		types.PythonRuntime,
-	} {
+	for _, runtime := range langs {
 		count, err := runtime7dayCount(runtime)
 		if err != nil {
 			return nil, err
@@ -430,6 +506,67 @@ func Runtime7dayCount() ([]vo.RuntimeDayCnt, error) {
 	return res, nil
 }
 
+// AdvertiseRuntimes records that the host serves the runtimes
+func AdvertiseRuntimes(host string, runtimes []types.Runtime) error {
+	db := getInstance()
+	stmt, err := db.Prepare("insert into runtime (host, lang, version, update_at) VALUES (?,?,?,?) ON DUPLICATE KEY UPDATE update_at = VALUES(update_at)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	now := time.Now()
+	for _, rt := range runtimes {
+		if _, err = stmt.Exec(host, rt.Lang, rt.Version, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ListRuntimes returns the runtimes advertised by any host since the time
+func ListRuntimes(since time.Time) ([]types.Runtime, error) {
+	db := getInstance()
+	rows, err := db.Query("select distinct lang,version from runtime where update_at >= ? order by lang,version", since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make([]types.Runtime, 0)
+	for rows.Next() {
+		var rt types.Runtime
+		if err = rows.Scan(&rt.Lang, &rt.Version); err != nil {
+			return nil, err
+		}
+		res = append(res, rt)
+	}
+
+	return res, nil
+}
+
+// ListHostRuntimes returns the runtimes advertised by every host since the time
+func ListHostRuntimes(since time.Time) (map[string][]types.Runtime, error) {
+	db := getInstance()
+	rows, err := db.Query("select host,lang,version from runtime where update_at >= ? order by host,lang,version", since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make(map[string][]types.Runtime)
+	for rows.Next() {
+		var (
+			host string
+			rt   types.Runtime
+		)
+		if err = rows.Scan(&host, &rt.Lang, &rt.Version); err != nil {
+			return nil, err
+		}
+		res[host] = append(res[host], rt)
+	}
+
+	return res, nil
+}
+
 //func GetUserById(id string) (*orm.User, error) {
 //	db := getInstance()
 //	stmt, err := db.Prepare("select username,email,id_number,work_status,age from user where id = ?")
@@ -453,3 +590,12 @@ func Runtime7dayCount() ([]vo.RuntimeDayCnt, error) {
 //	}
 //	return nil, nil
 //}
+
+// nullJSON stores an empty JSON column as NULL
+func nullJSON(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+
+	return string(data)
+}
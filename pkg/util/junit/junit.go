@@ -0,0 +1,133 @@
+package junit
+
+import (
+	"encoding/xml"
+	"errors"
+	"strings"
+)
+
+type Status string
+
+const (
+	Passed  Status = "passed"
+	Failed  Status = "failed"
+	Errored Status = "error"
+	Skipped Status = "skipped"
+)
+
+// TestCase is a testcase element of a JUnit XML report
+type TestCase struct {
+	Name      string
+	Classname string
+	// Time in seconds
+	Time    float64
+	Status  Status
+	Message string
+}
+
+// FullName joins the classname and the name, such as tests.test_main.test_add
+func (t *TestCase) FullName() string {
+	if t.Classname == "" {
+		return t.Name
+	}
+
+	return t.Classname + "." + t.Name
+}
+
+type suites struct {
+	Suites []suite `xml:"testsuite"`
+}
+
+type suite struct {
+	Suites []suite    `xml:"testsuite"`
+	Cases  []testCase `xml:"testcase"`
+}
+
+type testCase struct {
+	Name      string   `xml:"name,attr"`
+	Classname string   `xml:"classname,attr"`
+	Time      float64  `xml:"time,attr"`
+	Failure   *message `xml:"failure"`
+	Error     *message `xml:"error"`
+	Skipped   *message `xml:"skipped"`
+}
+
+type message struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func (m *message) String() string {
+	text := strings.TrimSpace(m.Text)
+	switch {
+	case m.Message == "":
+		return text
+	case text == "" || strings.Contains(text, m.Message):
+		return m.Message
+	default:
+		return m.Message + "\n" + text
+	}
+}
+
+// Parse reads the testcases of a report, the root is either testsuites or testsuite
+func Parse(data []byte) ([]TestCase, error) {
+	var root struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+
+	var ss []suite
+	switch root.XMLName.Local {
+	case "testsuites":
+		r := suites{}
+		if err := xml.Unmarshal(data, &r); err != nil {
+			return nil, err
+		}
+		ss = r.Suites
+	case "testsuite":
+		s := suite{}
+		if err := xml.Unmarshal(data, &s); err != nil {
+			return nil, err
+		}
+		ss = []suite{s}
+	default:
+		return nil, errors.New("the root element must be testsuites or testsuite")
+	}
+
+	var res []TestCase
+	for i := range ss {
+		res = appendSuite(res, &ss[i])
+	}
+
+	return res, nil
+}
+
+func appendSuite(res []TestCase, s *suite) []TestCase {
+	for _, c := range s.Cases {
+		tc := TestCase{
+			Name:      c.Name,
+			Classname: c.Classname,
+			Time:      c.Time,
+			Status:    Passed,
+		}
+		switch {
+		case c.Error != nil:
+			tc.Status = Errored
+			tc.Message = c.Error.String()
+		case c.Failure != nil:
+			tc.Status = Failed
+			tc.Message = c.Failure.String()
+		case c.Skipped != nil:
+			tc.Status = Skipped
+			tc.Message = c.Skipped.String()
+		}
+		res = append(res, tc)
+	}
+	for i := range s.Suites {
+		res = appendSuite(res, &s.Suites[i])
+	}
+
+	return res
+}
@@ -0,0 +1,53 @@
+package junit
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	pytest := `<?xml version="1.0" encoding="utf-8"?>
+<testsuites><testsuite name="pytest" tests="4" failures="1" errors="1" skipped="1">
+<testcase classname="tests.test_main" name="test_add" time="0.001"/>
+<testcase classname="tests.test_main" name="test_sub" time="0.002"><failure message="assert 1 == 2">def test_sub():
+&gt;       assert sub(2, 1) == 2
+E       assert 1 == 2</failure></testcase>
+<testcase classname="tests.test_main" name="test_div" time="0"><error message="ZeroDivisionError">trace</error></testcase>
+<testcase classname="tests.test_main" name="test_skip" time="0"><skipped message="not ready"/></testcase>
+</testsuite></testsuites>`
+	cases, err := Parse([]byte(pytest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		name   string
+		status Status
+	}{
+		{"tests.test_main.test_add", Passed},
+		{"tests.test_main.test_sub", Failed},
+		{"tests.test_main.test_div", Errored},
+		{"tests.test_main.test_skip", Skipped},
+	}
+	if len(cases) != len(want) {
+		t.Fatalf("got %d cases, want %d", len(cases), len(want))
+	}
+	for i := range want {
+		if cases[i].FullName() != want[i].name || cases[i].Status != want[i].status {
+			t.Errorf("case %d = %s %s, want %s %s", i, cases[i].FullName(), cases[i].Status, want[i].name, want[i].status)
+		}
+	}
+	if cases[1].Message == "" || cases[1].Time != 0.002 {
+		t.Errorf("unexpected failure %+v", cases[1])
+	}
+
+	// jest-junit and go-junit-report nest or omit testsuites differently
+	nested := `<testsuite name="root"><testsuite name="pkg"><testcase name="TestA"/></testsuite></testsuite>`
+	cases, err = Parse([]byte(nested))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cases) != 1 || cases[0].FullName() != "TestA" {
+		t.Errorf("unexpected cases %+v", cases)
+	}
+
+	if _, err = Parse([]byte(`<html></html>`)); err == nil {
+		t.Error("Parse() expects an error")
+	}
+}
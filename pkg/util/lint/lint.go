@@ -0,0 +1,311 @@
+package lint
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Severity string
+
+const (
+	Error   Severity = "error"
+	Warning Severity = "warning"
+	// Info is a convention or refactoring hint
+	Info Severity = "info"
+)
+
+// The machine-readable formats of the analyzers
+const (
+	FormatPylint = "pylint-json"
+	FormatFlake8 = "flake8"
+	FormatESLint = "eslint-json"
+	FormatGoVet  = "go-vet-json"
+)
+
+// Finding is a problem reported by an analyzer
+type Finding struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Rule     string   `json:"rule,omitempty"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// CompileError is returned when the code cannot be analyzed since it does not compile
+type CompileError struct {
+	// Output is the errors of the compiler
+	Output string
+}
+
+func (e *CompileError) Error() string {
+	return "the code does not compile"
+}
+
+var parsers = map[string]func(data []byte) ([]Finding, error){
+	FormatPylint: parsePylint,
+	FormatFlake8: parseFlake8,
+	FormatESLint: parseESLint,
+	FormatGoVet:  parseGoVet,
+}
+
+// Supported reports whether the format can be parsed
+func Supported(format string) bool {
+	_, ok := parsers[format]
+
+	return ok
+}
+
+// Parse reads the findings of a report, they are sorted by file and position
+func Parse(format string, data []byte) ([]Finding, error) {
+	parse, ok := parsers[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported format %s", format)
+	}
+	res, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		a, b := res[i], res[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+
+		return a.Column < b.Column
+	})
+
+	return res, nil
+}
+
+// Count returns the number of findings of every severity
+func Count(findings []Finding) map[Severity]int {
+	res := make(map[Severity]int)
+	for _, f := range findings {
+		res[f.Severity]++
+	}
+
+	return res
+}
+
+// parsePylint reads the output of pylint --output-format=json
+func parsePylint(data []byte) ([]Finding, error) {
+	var messages []struct {
+		Type      string `json:"type"`
+		Path      string `json:"path"`
+		Line      int    `json:"line"`
+		Column    int    `json:"column"`
+		Symbol    string `json:"symbol"`
+		MessageID string `json:"message-id"`
+		Message   string `json:"message"`
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, err
+	}
+	res := make([]Finding, 0, len(messages))
+	for _, m := range messages {
+		severity := Info
+		switch m.Type {
+		case "fatal", "error":
+			severity = Error
+		case "warning":
+			severity = Warning
+		}
+		rule := m.Symbol
+		if rule == "" {
+			rule = m.MessageID
+		}
+		res = append(res, Finding{
+			File: m.Path,
+			Line: m.Line,
+			// the columns of pylint start at 0
+			Column:   m.Column + 1,
+			Rule:     rule,
+			Severity: severity,
+			Message:  m.Message,
+		})
+	}
+
+	return res, nil
+}
+
+var flake8Line = regexp.MustCompile(`^(.+?):(\d+):(\d+): ([A-Z]+\d+) (.*)$`)
+
+// parseFlake8 reads the default format of flake8, such as "./main.py:1:1: F401 'os' imported but unused"
+func parseFlake8(data []byte) ([]Finding, error) {
+	var res []Finding
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+		m := flake8Line.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("unexpected line %q", line)
+		}
+		lineNum, _ := strconv.Atoi(m[2])
+		col, _ := strconv.Atoi(m[3])
+		res = append(res, Finding{
+			File:     m[1],
+			Line:     lineNum,
+			Column:   col,
+			Rule:     m[4],
+			Severity: flake8Severity(m[4]),
+			Message:  m[5],
+		})
+	}
+
+	return res, s.Err()
+}
+
+// flake8Severity maps the code prefixes, F are the errors of pyflakes and E9 are syntax errors,
+// the other E and W codes are style warnings of pycodestyle, and the rest come from plugins such as mccabe
+func flake8Severity(code string) Severity {
+	switch {
+	case strings.HasPrefix(code, "F"), strings.HasPrefix(code, "E9"):
+		return Error
+	case strings.HasPrefix(code, "E"), strings.HasPrefix(code, "W"):
+		return Warning
+	default:
+		return Info
+	}
+}
+
+// parseESLint reads the output of eslint -f json
+func parseESLint(data []byte) ([]Finding, error) {
+	var files []struct {
+		FilePath string `json:"filePath"`
+		Messages []struct {
+			RuleID   string `json:"ruleId"`
+			Severity int    `json:"severity"`
+			Message  string `json:"message"`
+			Line     int    `json:"line"`
+			Column   int    `json:"column"`
+		} `json:"messages"`
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	if err := json.Unmarshal(data, &files); err != nil {
+		return nil, err
+	}
+	var res []Finding
+	for _, f := range files {
+		for _, m := range f.Messages {
+			severity := Warning
+			if m.Severity == 2 {
+				severity = Error
+			}
+			rule := m.RuleID
+			if rule == "" {
+				// a message without a rule is a parsing error
+				rule, severity = "syntax", Error
+			}
+			res = append(res, Finding{
+				File:     f.FilePath,
+				Line:     m.Line,
+				Column:   m.Column,
+				Rule:     rule,
+				Severity: severity,
+				Message:  m.Message,
+			})
+		}
+	}
+
+	return res, nil
+}
+
+// parseGoVet reads the output of go vet -json, which is a comment line of every package
+// followed by an object of the findings by package and analyzer
+func parseGoVet(data []byte) ([]Finding, error) {
+	var (
+		buf       bytes.Buffer
+		compiling []string
+	)
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		line := s.Text()
+		switch {
+		case strings.HasPrefix(line, "#"):
+			continue
+		case line != "" && !strings.ContainsAny(line[:1], "{} \t"):
+			// the objects are indented, the errors of the code that does not compile are printed as text
+			compiling = append(compiling, strings.TrimPrefix(line, "vet: "))
+			continue
+		}
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if len(compiling) > 0 {
+		return nil, &CompileError{Output: strings.Join(compiling, "\n")}
+	}
+
+	var res []Finding
+	dec := json.NewDecoder(&buf)
+	for {
+		var pkgs map[string]map[string]json.RawMessage
+		err := dec.Decode(&pkgs)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, analyzers := range pkgs {
+			for analyzer, raw := range analyzers {
+				var diags []struct {
+					Posn    string `json:"posn"`
+					Message string `json:"message"`
+				}
+				// an analyzer that failed reports an object with an error instead of a list
+				if err = json.Unmarshal(raw, &diags); err != nil {
+					var failure struct {
+						Error string `json:"error"`
+					}
+					if json.Unmarshal(raw, &failure) != nil {
+						return nil, err
+					}
+					res = append(res, Finding{Rule: analyzer, Severity: Error, Message: failure.Error})
+					continue
+				}
+				for _, d := range diags {
+					f := Finding{Rule: analyzer, Severity: Error, Message: d.Message}
+					f.File, f.Line, f.Column = position(d.Posn)
+					res = append(res, f)
+				}
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// position splits a position such as main.go:3:2
+func position(posn string) (file string, line, col int) {
+	parts := strings.Split(posn, ":")
+	if len(parts) >= 3 {
+		if l, err := strconv.Atoi(parts[len(parts)-2]); err == nil {
+			c, _ := strconv.Atoi(parts[len(parts)-1])
+			return strings.Join(parts[:len(parts)-2], ":"), l, c
+		}
+	}
+
+	return posn, 0, 0
+}
@@ -0,0 +1,71 @@
+package lint
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		format string
+		data   string
+		want   []Finding
+	}{
+		{
+			FormatPylint,
+			`[{"type": "convention", "module": "main", "obj": "", "line": 1, "column": 0, "path": "main.py",
+"symbol": "missing-module-docstring", "message": "Missing module docstring", "message-id": "C0114"},
+{"type": "error", "module": "main", "obj": "", "line": 3, "column": 4, "path": "main.py",
+"symbol": "undefined-variable", "message": "Undefined variable 'x'", "message-id": "E0602"}]`,
+			[]Finding{
+				{File: "main.py", Line: 1, Column: 1, Rule: "missing-module-docstring", Severity: Info, Message: "Missing module docstring"},
+				{File: "main.py", Line: 3, Column: 5, Rule: "undefined-variable", Severity: Error, Message: "Undefined variable 'x'"},
+			},
+		},
+		{
+			FormatFlake8,
+			"./main.py:2:80: E501 line too long (82 > 79 characters)\n./main.py:1:1: F401 'os' imported but unused\n",
+			[]Finding{
+				{File: "./main.py", Line: 1, Column: 1, Rule: "F401", Severity: Error, Message: "'os' imported but unused"},
+				{File: "./main.py", Line: 2, Column: 80, Rule: "E501", Severity: Warning, Message: "line too long (82 > 79 characters)"},
+			},
+		},
+		{
+			FormatESLint,
+			`[{"filePath": "/box/main.js", "messages": [{"ruleId": "no-unused-vars", "severity": 2, "message": "'a' is defined but never used.", "line": 1, "column": 7},
+{"ruleId": "semi", "severity": 1, "message": "Missing semicolon.", "line": 2, "column": 10}]}]`,
+			[]Finding{
+				{File: "/box/main.js", Line: 1, Column: 7, Rule: "no-unused-vars", Severity: Error, Message: "'a' is defined but never used."},
+				{File: "/box/main.js", Line: 2, Column: 10, Rule: "semi", Severity: Warning, Message: "Missing semicolon."},
+			},
+		},
+		{
+			FormatGoVet,
+			"# command-line-arguments\n{\n\t\"command-line-arguments\": {\n\t\t\"printf\": [\n\t\t\t{\n\t\t\t\t\"posn\": \"/box/main.go:6:2\",\n" +
+				"\t\t\t\t\"message\": \"fmt.Printf format %d has arg s of wrong type string\"\n\t\t\t}\n\t\t]\n\t}\n}\n",
+			[]Finding{
+				{File: "/box/main.go", Line: 6, Column: 2, Rule: "printf", Severity: Error, Message: "fmt.Printf format %d has arg s of wrong type string"},
+			},
+		},
+		{FormatGoVet, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			got, err := Parse(tt.format, []byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Parse(FormatFlake8, []byte("not a finding")); err == nil {
+		t.Error("Parse() accepts an unexpected line")
+	}
+	_, err := Parse(FormatGoVet, []byte("# command-line-arguments\nvet: ./main.go:5:2: undefined: x\n"))
+	if ce, ok := err.(*CompileError); !ok || ce.Output != "./main.go:5:2: undefined: x" {
+		t.Errorf("Parse() of the code that does not compile = %v", err)
+	}
+}
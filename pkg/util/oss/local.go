@@ -0,0 +1,118 @@
+package oss
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Local is a Storage in a local directory, the content types are not kept
+type Local struct {
+	root string
+}
+
+func NewLocal(root string) (*Local, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &Local{root: root}, nil
+}
+
+// file returns the local path of a storage path, which cannot escape the root
+func (l *Local) file(p string) string {
+	return filepath.Join(l.root, filepath.FromSlash(path.Clean("/"+p)))
+}
+
+func (l *Local) Get(_ context.Context, path string) ([]byte, error) {
+	return os.ReadFile(l.file(path))
+}
+
+func (l *Local) Open(_ context.Context, path string) (io.ReadCloser, error) {
+	return os.Open(l.file(path))
+}
+
+func (l *Local) Put(_ context.Context, path string, data io.Reader, _ int64, _ string) error {
+	name := l.file(path)
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	// the file is written aside and renamed, so that a reader never sees a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".put-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = io.Copy(tmp, data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), name)
+}
+
+func (l *Local) List(_ context.Context, prefix string) ([]string, error) {
+	// the prefix is not always a directory, such as task/1/code matching task/1/code.zip
+	dir := l.file(prefix)
+	if !strings.HasSuffix(prefix, "/") {
+		dir = filepath.Dir(dir)
+	}
+	var res []string
+	err := filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(l.root, name)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if strings.HasPrefix(rel, strings.TrimPrefix(prefix, "/")) {
+			res = append(res, rel)
+		}
+
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (l *Local) Copy(ctx context.Context, src, dst string) error {
+	f, err := os.Open(l.file(src))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return l.Put(ctx, dst, f, -1, "")
+}
+
+func (l *Local) Move(_ context.Context, src, dst string) error {
+	name := l.file(dst)
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	if err := os.Rename(l.file(src), name); err != nil {
+		return fmt.Errorf("failed to move %s to %s, err: %w", src, dst, err)
+	}
+
+	return nil
+}
+
+func (l *Local) Remove(_ context.Context, path string) error {
+	return os.Remove(l.file(path))
+}
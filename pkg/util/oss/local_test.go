@@ -0,0 +1,69 @@
+package oss
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = PutTextFile(ctx, s, []byte("print(1)"), GetCodePath(1)); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Put(ctx, GetCodeArchivePath(1, "zip"), strings.NewReader("zip"), 3, MIMEPlain); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Copy(ctx, GetCodePath(1), "tmp/1/code"); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Move(ctx, "tmp/1/code", GetCodePath(2)); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := s.Open(ctx, GetCodePath(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if string(data) != "print(1)" {
+		t.Errorf("Open() reads %q", data)
+	}
+	if _, err = s.Get(ctx, "tmp/1/code"); !IsNotExist(err) {
+		t.Errorf("Get() of a moved file returns %v", err)
+	}
+	// the paths cannot escape the root
+	if _, err = s.Get(ctx, "../../"+GetCodePath(1)); err != nil {
+		t.Errorf("Get() of a cleaned path returns %v", err)
+	}
+
+	got, err := s.List(ctx, "task/1/code")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(got)
+	if want := []string{"task/1/code", "task/1/code.zip"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("List() = %v, want %v", got, want)
+	}
+	if got, _ = s.List(ctx, "task/"); len(got) != 3 {
+		t.Errorf("List() of a dir = %v", got)
+	}
+	if got, err = s.List(ctx, "batch/"); err != nil || len(got) != 0 {
+		t.Errorf("List() of a missing dir = %v, %v", got, err)
+	}
+
+	if err = s.Remove(ctx, GetCodePath(2)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.Get(ctx, GetCodePath(2)); !IsNotExist(err) {
+		t.Errorf("Get() of a removed file returns %v", err)
+	}
+}
@@ -1,11 +1,11 @@
 package oss
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
-	"os"
+	"io/fs"
 
 	"github.com/sirupsen/logrus"
 
@@ -54,6 +54,16 @@ func (c *Client) Get(ctx context.Context, path string) ([]byte, error) {
 	return buffer, nil
 }
 
+// Open streams an object, the error of a missing object is returned by the first read
+func (c *Client) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	return c.client.GetObject(ctx, c.bucket, path, minio.GetObjectOptions{})
+}
+
+// IsNotExist reports whether the error of a Storage is caused by a missing file
+func IsNotExist(err error) bool {
+	return errors.Is(err, fs.ErrNotExist) || minio.ToErrorResponse(err).Code == "NoSuchKey"
+}
+
 func (c *Client) Put(ctx context.Context, path string, data io.Reader, len int64, contentType string) error {
 	// gin.MIMEPlain
 	_, err := c.client.PutObject(ctx, c.bucket, path, data, len, minio.PutObjectOptions{ContentType: contentType})
@@ -62,6 +72,18 @@ func (c *Client) Put(ctx context.Context, path string, data io.Reader, len int64
 	return err
 }
 
+func (c *Client) List(ctx context.Context, prefix string) ([]string, error) {
+	var res []string
+	for object := range c.client.ListObjects(ctx, c.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if object.Err != nil {
+			return nil, object.Err
+		}
+		res = append(res, object.Key)
+	}
+
+	return res, nil
+}
+
 func (c *Client) Remove(ctx context.Context, path string) error {
 	return c.client.RemoveObject(ctx, c.bucket, path, minio.RemoveObjectOptions{})
 }
@@ -89,21 +111,3 @@ func (c *Client) Move(ctx context.Context, src, dst string) error {
 
 	return nil
 }
-
-func (c *Client) PutLocalTextFile(ctx context.Context, path, ossPath string) error {
-	file, err := os.Open(path)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	fstat, err := file.Stat()
-	if err != nil {
-		return err
-	}
-	return c.Put(ctx, ossPath, file, fstat.Size(), MIMEPlain)
-}
-
-func (c *Client) PutTextFile(ctx context.Context, data []byte, ossPath string) error {
-	return c.Put(ctx, ossPath, bytes.NewReader(data), int64(len(data)), MIMEPlain)
-}
@@ -11,12 +11,18 @@ const (
 	DefaultCodeFileName    = "code"
 	DefaultTmpDir          = "tmp"
 	DefaultVerificationDir = "verification"
+	DefaultSimilarityName  = "similarity.json"
 )
 
 func GetBatchDir(batchID int) string {
 	return path.Join(DefaultBatchDir, strconv.Itoa(batchID))
 }
 
+// GetSimilarityPath returns the path of the latest similarity report of a batch
+func GetSimilarityPath(batchID int) string {
+	return path.Join(GetBatchDir(batchID), DefaultSimilarityName)
+}
+
 func GetTaskDir(taskID int) string {
 	return path.Join(DefaultTaskDir, strconv.Itoa(taskID))
 }
@@ -25,6 +31,11 @@ func GetCodePath(taskID int) string {
 	return path.Join(GetTaskDir(taskID), DefaultCodeFileName)
 }
 
+// GetCodeArchivePath returns the path of the archive of a project submission
+func GetCodeArchivePath(taskID int, format string) string {
+	return path.Join(GetTaskDir(taskID), DefaultCodeFileName+"."+format)
+}
+
 func GetUserTempDir(uid int) string {
 	return path.Join(DefaultTmpDir, strconv.Itoa(uid))
 }
@@ -0,0 +1,56 @@
+package oss
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+
+	"github.com/vincent-vinf/code-validator/pkg/util/config"
+)
+
+// Storage stores the files of the batches and the tasks by slash separated paths
+type Storage interface {
+	Get(ctx context.Context, path string) ([]byte, error)
+	// Open streams a file, the reader must be closed
+	Open(ctx context.Context, path string) (io.ReadCloser, error)
+	Put(ctx context.Context, path string, data io.Reader, len int64, contentType string) error
+	// List returns the paths of the files under the prefix, recursively
+	List(ctx context.Context, prefix string) ([]string, error)
+	Copy(ctx context.Context, src, dst string) error
+	Move(ctx context.Context, src, dst string) error
+	Remove(ctx context.Context, path string) error
+}
+
+var (
+	_ Storage = (*Client)(nil)
+	_ Storage = (*Local)(nil)
+)
+
+// NewStorage returns the local storage if a directory is configured, MinIO otherwise
+func NewStorage(minio config.Minio, storage config.Storage) (Storage, error) {
+	if storage.LocalDir != "" {
+		return NewLocal(storage.LocalDir)
+	}
+
+	return NewClient(minio)
+}
+
+func PutTextFile(ctx context.Context, s Storage, data []byte, ossPath string) error {
+	return s.Put(ctx, ossPath, bytes.NewReader(data), int64(len(data)), MIMEPlain)
+}
+
+func PutLocalTextFile(ctx context.Context, s Storage, path, ossPath string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	fstat, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	return s.Put(ctx, ossPath, file, fstat.Size(), MIMEPlain)
+}